@@ -0,0 +1,80 @@
+package golog
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+)
+
+func SetAtomicWrite(enabled bool) {
+	std().SetAtomicWrite(enabled)
+}
+
+// SetAtomicWrite makes every record replace the log file with a copy that
+// has the record appended: the copy is written to a temporary file in
+// the same directory, synced and renamed over the log file, so readers
+// see either the old or the new file, never a torn record, even on a
+// full disk or a crash. Each record copies the whole file, so this is
+// only meant for small, low volume logs read by parsers that can not
+// cope with partial lines. It has no effect on compressed files, outputs
+// that are not files.
+//
+// Records are written synchronously even with DropWithTimeout.
+func (l *Logger) SetAtomicWrite(enabled bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.atomicWrite = enabled
+}
+
+// appendAtomic replaces path with a copy that has rec appended and
+// returns the new file, open and positioned at its end. The copy keeps
+// the permissions of path, or gets perm if there is no file yet.
+func appendAtomic(path string, rec []byte, perm os.FileMode) (f *os.File, err error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if old, err := os.Open(path); err == nil {
+		if fi, err := old.Stat(); err == nil {
+			perm = fi.Mode().Perm()
+		}
+		_, err = io.Copy(tmp, old)
+		old.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if _, err := tmp.Write(rec); err != nil {
+		return nil, err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		return nil, err
+	}
+	if err := tmp.Sync(); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return nil, err
+	}
+	return tmp, nil
+}
+
+// writeAtomic is the SetAtomicWrite version of write; the new file
+// becomes the output. l.mu must be held.
+func (l *Logger) writeAtomic(rec []byte) error {
+	return l.tracked(func() error {
+		f, err := appendAtomic(l.file.path, rec, l.file.perm)
+		if err != nil {
+			return err
+		}
+		l.file.swap(f)
+		return nil
+	})
+}
@@ -0,0 +1,72 @@
+package golog
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetAtomicWrite(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	if err := os.WriteFile(path, []byte("existing\n"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	l := &Logger{level: LEVEL_INFO}
+	if err := l.openFile(path); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { l.out.(io.Closer).Close() }()
+	l.SetAtomicWrite(true)
+
+	for i := 0; i < 3; i++ {
+		if err := l.output(LEVEL_INFO, "line %d", i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, _ := os.ReadFile(path)
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != 4 || lines[0] != "existing" || !strings.HasSuffix(lines[3], ": line 2") {
+		t.Fatalf("log file has %q", data)
+	}
+	fi, err := os.Stat(path)
+	if err != nil || fi.Mode().Perm() != 0640 {
+		t.Errorf("mode %v, %v; want 0640", fi.Mode(), err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("temporary files left: %v", entries)
+	}
+
+	// the output follows the replaced file.
+	l.SetAtomicWrite(false)
+	l.output(LEVEL_INFO, "appended")
+	data, _ = os.ReadFile(path)
+	if strings.Count(string(data), "\n") != 5 || !strings.HasSuffix(string(data), ": appended\n") {
+		t.Fatalf("log file has %q", data)
+	}
+}
+
+func TestAtomicWritePerm(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	l := &Logger{level: LEVEL_INFO}
+	if err := l.openFile(path); err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	l.file.perm = 0600
+	l.SetAtomicWrite(true)
+
+	// a removed file is created again with the permissions of the logger.
+	os.Remove(path)
+	if err := l.output(LEVEL_INFO, "recreated"); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := os.Stat(path)
+	if err != nil || fi.Mode().Perm() != 0600 {
+		t.Errorf("mode %v, %v; want 0600", fi.Mode(), err)
+	}
+}
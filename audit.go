@@ -0,0 +1,36 @@
+package golog
+
+import (
+	"os"
+	"time"
+)
+
+// _audit is the audit stream: a logger of its own, with its own file and
+// lock, so it keeps working whatever happens to the main log.
+var _audit = &Logger{out: os.Stderr, level: LEVEL_VERBOSE, microseconds: true,
+	shortfile: true, syncWrites: true}
+
+// Audit logs an event that must not be lost, e.g. a login or a
+// permission change, to the audit file set by SetAuditFile (stderr until
+// then). Audit records are never filtered by level, have the usual
+// header and are synced to disk before Audit returns.
+func Audit(format string, v ...interface{}) {
+	_audit.outputDepth(2, LEVEL_NOTICE, "", format, v...)
+}
+
+// SetAuditFile sends audit records to path. The file is independent of
+// the main log file: SetFile, SetLevel and the like do not affect it.
+func SetAuditFile(path string) error {
+	s, err := openFileSink(path, false, 0)
+	if err != nil {
+		return err
+	}
+	_audit.setFile(s)
+	return nil
+}
+
+// EnableAuditRotate rotates the audit file like EnableRotate does the main
+// log file. Rotated audit files are never deleted.
+func EnableAuditRotate(period time.Duration) error {
+	return _audit.enableRotate(period)
+}
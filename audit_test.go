@@ -0,0 +1,82 @@
+package golog
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+type syncRecorder struct {
+	bytes.Buffer
+	syncs   int
+	written []int // buffer length at each sync
+}
+
+func (s *syncRecorder) Sync() error {
+	s.syncs++
+	s.written = append(s.written, s.Len())
+	return nil
+}
+
+func captureAudit(t *testing.T, w *syncRecorder) {
+	orig := _audit
+	_audit = &Logger{out: w, level: LEVEL_VERBOSE, microseconds: true,
+		shortfile: true, syncWrites: true}
+	t.Cleanup(func() { _audit = orig })
+}
+
+func TestAudit(t *testing.T) {
+	main := captureGlobal(t, LEVEL_EMERGENCY)
+	audit := &syncRecorder{}
+	captureAudit(t, audit)
+
+	Error("not audited")
+	Audit("user %s logged in", "alice")
+	Audit("user %s granted admin", "bob")
+
+	re := regexp.MustCompile(`^` +
+		headerRe + `\[NOTICE\] audit_test.go:\d+: user alice logged in\n` +
+		headerRe + `\[NOTICE\] audit_test.go:\d+: user bob granted admin\n$`)
+	if !re.MatchString(audit.String()) {
+		t.Errorf("audit got %q", audit.String())
+	}
+	if main.Len() != 0 {
+		t.Errorf("main log got %q", main.String())
+	}
+	if audit.syncs != 2 || audit.written[0] == 0 || audit.written[1] != audit.Len() {
+		t.Errorf("synced %d times at %v, want after each of 2 records", audit.syncs, audit.written)
+	}
+}
+
+type brokenWriter struct{}
+
+func (brokenWriter) Write(p []byte) (int, error) { return 0, errors.New("disk gone") }
+
+func TestSetAuditFile(t *testing.T) {
+	captureGlobal(t, LEVEL_VERBOSE)
+	std().out = brokenWriter{}
+	captureAudit(t, &syncRecorder{})
+
+	path := filepath.Join(t.TempDir(), "audit.log")
+	if err := SetAuditFile(path); err != nil {
+		t.Fatal(err)
+	}
+	defer _audit.out.(io.Closer).Close()
+
+	Info("lost")
+	Audit("still audited")
+
+	data, _ := os.ReadFile(path)
+	re := regexp.MustCompile(`^` + headerRe + `\[NOTICE\] audit_test.go:\d+: still audited\n$`)
+	if !re.Match(data) {
+		t.Errorf("audit file has %q", data)
+	}
+
+	if err := SetAuditFile(filepath.Join(t.TempDir(), "no", "such", "dir")); err == nil {
+		t.Error("SetAuditFile to a missing dir succeeded")
+	}
+}
@@ -0,0 +1,96 @@
+package golog
+
+import (
+	"maps"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// max number of call sites kept by the caller cache; programs with more
+// log statements look the rest up every time.
+const maxCallerCache = 1 << 14
+
+const callerShards = 64
+
+// callerInfo is a call site. pos, its file:line rendered once, is only
+// set for a call site from the cache; file is then a part of pos.
+type callerInfo struct {
+	file string
+	line int
+	pos  string
+}
+
+type callerShard struct {
+	mu sync.Mutex                             // serializes inserts
+	m  atomic.Pointer[map[uintptr]callerInfo] // copy on write, read without a lock
+}
+
+var (
+	callerCache     [callerShards]callerShard
+	callerCacheSize int64
+	callerCacheOn   atomic.Bool
+)
+
+// SetCallerCache turns the caller cache on or off, the default. The
+// cache keeps the file:line of each call site, rendered, by program
+// counter, so only the stack walk is left to do per record. It grows
+// with the number of call sites, up to 16384.
+func SetCallerCache(enabled bool) {
+	callerCacheOn.Store(enabled)
+}
+
+// caller is runtime.Caller(skip) returning only file and line, see
+// callerSite.
+func caller(skip int) (file string, line int, ok bool) {
+	ci, ok := callerSite(skip + 1)
+	return ci.file, ci.line, ok
+}
+
+// callerSite looks up the call site skip frames up like runtime.Caller,
+// with the cache on through the cache: a call site always maps to the
+// same file and line, only the stack walk has to be done each time.
+func callerSite(skip int) (ci callerInfo, ok bool) {
+	var pcs [1]uintptr
+	// +1 for callerSite itself, +1 as runtime.Callers counts itself.
+	if runtime.Callers(skip+2, pcs[:]) == 0 {
+		return ci, false
+	}
+	pc := pcs[0]
+	on := callerCacheOn.Load()
+	shard := &callerCache[(pc>>3)%callerShards]
+
+	if on {
+		if m := shard.m.Load(); m != nil {
+			if ci, ok := (*m)[pc]; ok {
+				return ci, true
+			}
+		}
+	}
+
+	// a fresh slice, passing pcs would move it to the heap on every call.
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	if frame.File == "" {
+		return ci, false
+	}
+	ci = callerInfo{file: frame.File, line: frame.Line}
+	if on && atomic.LoadInt64(&callerCacheSize) < maxCallerCache {
+		pos := []byte(frame.File + ":")
+		itoa(&pos, frame.Line, -1)
+		ci.pos = string(pos)
+		ci.file = ci.pos[:len(frame.File)]
+
+		shard.mu.Lock()
+		m := map[uintptr]callerInfo{}
+		if old := shard.m.Load(); old != nil {
+			m = maps.Clone(*old)
+		}
+		if _, ok := m[pc]; !ok {
+			m[pc] = ci
+			shard.m.Store(&m)
+			atomic.AddInt64(&callerCacheSize, 1)
+		}
+		shard.mu.Unlock()
+	}
+	return ci, true
+}
@@ -0,0 +1,95 @@
+package golog
+
+import (
+	"bytes"
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func callerPair() (file string, line int, cfile string, cline int) {
+	_, file, line, _ = runtime.Caller(1)
+	cfile, cline, _ = caller(1)
+	return
+}
+
+func TestCallerCache(t *testing.T) {
+	SetCallerCache(true)
+	defer SetCallerCache(false)
+	for i := 0; i < 3; i++ {
+		// twice from the same call site: a miss, then hits.
+		file, line, cfile, cline := callerPair()
+		if file != cfile || line != cline {
+			t.Fatalf("runtime.Caller %s:%d, cached %s:%d", file, line, cfile, cline)
+		}
+	}
+	if _, _, ok := caller(1000); ok {
+		t.Fatal("caller beyond the stack succeeded")
+	}
+}
+
+func TestCachedCallerAllocs(t *testing.T) {
+	SetCallerCache(true)
+	defer SetCallerCache(false)
+	caller(1)
+	if n := testing.AllocsPerRun(100, func() { caller(1) }); n != 0 {
+		t.Errorf("cached caller lookup does %v allocations", n)
+	}
+}
+
+func BenchmarkRuntimeCaller(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		runtime.Caller(1)
+	}
+}
+
+func BenchmarkCachedCaller(b *testing.B) {
+	SetCallerCache(true)
+	defer SetCallerCache(false)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		caller(1)
+	}
+}
+
+func TestCallerCacheOff(t *testing.T) {
+	defer SetCallerCache(false)
+	_, file, _, _ := runtime.Caller(0)
+	for _, c := range []struct {
+		flags  int
+		prefix string
+		want   string
+	}{
+		{0, "", " caller_test.go:"},
+		{Lpackagefile, "", "/caller_test.go:"},
+		{0, file[:len(file)-len("caller_test.go")], " caller_test.go:"},
+	} {
+		var cached, uncached bytes.Buffer
+		l := &Logger{level: LEVEL_INFO, flags: c.flags, pathPrefix: c.prefix}
+		for _, o := range []struct {
+			on  bool
+			buf *bytes.Buffer
+		}{{true, &cached}, {false, &uncached}} {
+			SetCallerCache(o.on)
+			l.out = o.buf
+			for i := 0; i < 3; i++ {
+				l.Info("line")
+				func() { l.Info("closure") }()
+			}
+		}
+		stamp := regexp.MustCompile(`(?m)^\S+ \S+ `)
+		got, want := stamp.ReplaceAllString(uncached.String(), ""), stamp.ReplaceAllString(cached.String(), "")
+		if got != want || !strings.Contains(want, c.want) {
+			t.Errorf("%+v: uncached:\n%s\ncached:\n%s", c, got, want)
+		}
+	}
+}
+
+func BenchmarkUncachedCaller(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		caller(1)
+	}
+}
@@ -0,0 +1,43 @@
+package golog
+
+// AddCallerSkipFrames returns a child of l whose records report the
+// caller n frames further up the stack, for code that logs through
+// helpers or framework layers (reflection, RPC dispatch). Children of
+// the child add up their skips.
+//
+// A child logs through l: level and verbosity are those of l, and
+// SetLevel and SetVerbosity on the child change l. Its other setters do
+// not apply, configure l instead.
+func (l *Logger) AddCallerSkipFrames(n int) *Logger {
+	return &Logger{parent: l.root(), callerSkip: l.callerSkip + n, sampler: l.sampler, prefix: l.prefix, scope: l.scope}
+}
+
+// NewPrefixLogger returns a child of the global logger that puts prefix,
+// typically a subsystem name, before its messages:
+//
+//	db := golog.NewPrefixLogger("database")
+//	db.Info("connected to %s", addr) // ... [INFO] pool.go:42: database connected to ...
+//
+// Like any child it has the level and output of the global logger, so
+// SetLevel applies to it too.
+func NewPrefixLogger(prefix string) *Logger {
+	return &Logger{parent: std().root(), prefix: prefix}
+}
+
+// root returns the logger a child logs through, l itself if it is not a
+// child.
+func (l *Logger) root() *Logger {
+	if l.parent != nil {
+		return l.parent
+	}
+	return l
+}
+
+// joinPrefix puts the prefix of a child before the one of the record,
+// e.g. from ContextWithLogPrefix.
+func joinPrefix(outer, inner string) string {
+	if inner == "" {
+		return outer
+	}
+	return outer + " " + inner
+}
@@ -0,0 +1,59 @@
+package golog
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+)
+
+// logVia is a helper framework code would put between the call site and
+// the logger.
+func logVia(l *Logger, msg string) {
+	l.Info("%s", msg)
+}
+
+func TestAddCallerSkipFrames(t *testing.T) {
+	var buf bytes.Buffer
+	l := &Logger{out: &buf, level: LEVEL_INFO, microseconds: true, shortfile: true}
+	child := l.AddCallerSkipFrames(1)
+
+	l.Info("direct")
+	logVia(child, "via helper")
+	func() { logVia(child.AddCallerSkipFrames(1), "via two") }()
+	child.Debug("hidden")
+	child.SetLevel(LEVEL_DEBUG)
+	child.AddCallerSkipFrames(-1).Debug("debug")
+
+	re := regexp.MustCompile(`^` +
+		headerRe + `\[INFO\] child_test.go:20: direct\n` +
+		headerRe + `\[INFO\] child_test.go:21: via helper\n` +
+		headerRe + `\[INFO\] child_test.go:22: via two\n` +
+		headerRe + `\[DEBUG\] child_test.go:25: debug\n$`)
+	if !re.MatchString(buf.String()) {
+		t.Errorf("got:\n%s", buf.String())
+	}
+	if l.GetLevel() != LEVEL_DEBUG {
+		t.Errorf("SetLevel on the child did not change the parent")
+	}
+}
+
+func TestNewPrefixLogger(t *testing.T) {
+	orig := GetGlobalLogger()
+	defer SetGlobalLogger(orig)
+	var buf bytes.Buffer
+	SetGlobalLogger(&Logger{out: &buf, level: LEVEL_INFO, microseconds: true, shortfile: true})
+
+	db := NewPrefixLogger("database")
+	db.Info("connected")
+	db.Debug("hidden")
+	SetLevel(LEVEL_DEBUG) // logs the change, not from db
+	db.AddCallerSkipFrames(0).Debug("query")
+
+	re := regexp.MustCompile(`^` +
+		headerRe + `\[INFO\] child_test.go:47: database connected\n` +
+		headerRe + `\[CRITICAL\] log.go:\d+: set log level to 7\n` +
+		headerRe + `\[DEBUG\] child_test.go:50: database query\n$`)
+	if !re.MatchString(buf.String()) {
+		t.Errorf("got:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,82 @@
+package golog
+
+import "sync/atomic"
+
+// Clone returns a new Logger with the configuration of l but its own
+// state: changing the level, output or any other setting of one does
+// not affect the other. A plain log file is opened again, so closing
+// l does not close it for the clone; other outputs, like a writer given
+// to SetOutput, a compressed file or a sink, can not be duplicated and
+// are shared, the first Close closes them for both.
+//
+// Rotation, a TempLevel override and records buffered by
+// BufferUntilConfigured are not copied. The clone of a child is a child
+// of a clone of its parent.
+func (l *Logger) Clone() *Logger {
+	if l.parent != nil {
+		return &Logger{parent: l.parent.Clone(), callerSkip: l.callerSkip, prefix: l.prefix,
+			scope: l.scope, sampler: l.sampler.clone()}
+	}
+
+	l.tempMu.Lock()
+	level := atomic.LoadInt32(&l.level)
+	if len(l.tempStack) > 0 {
+		level = l.tempBase
+	}
+	l.tempMu.Unlock()
+
+	l.mu.RLock()
+	c := &Logger{
+		level:        level,
+		out:          l.out,
+		eol:          l.eol,
+		journal:      l.journal,
+		sink:         l.sink,
+		sinks:        append([]Sink(nil), l.sinks...),
+		maxLines:     l.maxLines,
+		syncWrites:   l.syncWrites,
+		atomicWrite:  l.atomicWrite,
+		saveTime:     l.saveTime,
+		multiline:    l.multiline,
+		contPrefix:   l.contPrefix,
+		quote:        l.quote,
+		flags:        l.flags,
+		pathPrefix:   l.pathPrefix,
+		style:        l.style,
+		headerFields: l.headerFields,
+		microseconds: l.microseconds,
+		shortfile:    l.shortfile,
+		batchRecords: l.batchRecords,
+		batchBytes:   l.batchBytes,
+		batchDelay:   l.batchDelay,
+		fanout:       l.fanout,
+		verbosity:    atomic.LoadInt32(&l.verbosity),
+		raw:          l.raw,
+		defaults:     l.defaults,
+		defaultsText: l.defaultsText,
+	}
+	writeTimeout, file := l.writeTimeout, l.file
+	l.mu.RUnlock()
+
+	// if it can not be opened the file is shared, like any other output.
+	if file != nil && !file.compress {
+		if s, err := openFileSink(file.path, false, file.perm); err == nil {
+			c.out, c.file = fileWriter{s}, s
+		}
+	}
+	if writeTimeout > 0 {
+		c.mu.Lock()
+		c.setWriteTimeout(writeTimeout)
+		c.mu.Unlock()
+	}
+
+	// copy on write, the clone gets its own copy once either side changes.
+	c.filters.Store(l.filters.Load())
+	c.pathLevels.Store(l.pathLevels.Load())
+	c.onError.Store(l.onError.Load())
+	c.metrics.Store(l.metrics.Load())
+	if limit := l.limit.Load(); limit != nil {
+		c.limit.Store(&writeLimit{slots: make(chan struct{}, cap(limit.slots)), drop: limit.drop})
+	}
+	return c
+}
@@ -0,0 +1,49 @@
+package golog
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestClone(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	l := &Logger{level: LEVEL_INFO, shortfile: true}
+	if err := l.openFile(path); err != nil {
+		t.Fatal(err)
+	}
+	l.SetDefaultFields(map[string]interface{}{"app": "x"})
+
+	c := l.Clone()
+	c.SetLevel(LEVEL_DEBUG)
+	if l.GetLevel() != LEVEL_INFO {
+		t.Errorf("SetLevel on the clone changed l to %d", l.GetLevel())
+	}
+	if c.FilePath() != path {
+		t.Errorf("clone FilePath() = %q", c.FilePath())
+	}
+
+	// the clone keeps its own file open.
+	l.Close()
+	c.Debug("from clone")
+	data, err := os.ReadFile(path)
+	if err != nil || !strings.Contains(string(data), "from clone app=x\n") {
+		t.Errorf("log file %v:\n%s", err, data)
+	}
+	c.Close()
+
+	// a child clones to a child of a clone.
+	var buf bytes.Buffer
+	l = &Logger{out: &buf, level: LEVEL_INFO}
+	child := NewSampledLogger(l, 1).AddCallerSkipFrames(0)
+	cc := child.Clone()
+	if cc.parent == nil || cc.parent == l || cc.sampler == child.sampler {
+		t.Errorf("clone of a child shares its parent or sampler")
+	}
+	cc.Info("via clone")
+	if !strings.Contains(buf.String(), "clone_test.go:45: via clone\n") {
+		t.Errorf("got:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,82 @@
+package golog
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// maxCommandLine is the longest line a CommandWriter buffers, longer ones
+// are logged in pieces.
+const maxCommandLine = 64 * 1024
+
+// CommandWriter returns a writer logging every line written to it to the
+// global logger at level, after tag, for the output of a subprocess:
+//
+//	cmd.Stdout = golog.CommandWriter(golog.LEVEL_INFO, "ffmpeg")
+//	cmd.Stderr = golog.CommandWriter(golog.LEVEL_WARNING, "ffmpeg")
+//	err := cmd.Run()
+//
+// Partial lines are kept until their end is written, or until Close,
+// which logs what is left. Every line is a record of its own, so the
+// output of commands writing at the same time does not mix.
+func CommandWriter(level int32, tag string) io.WriteCloser {
+	return std().CommandWriter(level, tag)
+}
+
+// CommandWriter returns a writer logging lines to l, see the
+// CommandWriter function.
+func (l *Logger) CommandWriter(level int32, tag string) io.WriteCloser {
+	return &commandWriter{l: l, level: level, tag: tag}
+}
+
+type commandWriter struct {
+	l     *Logger
+	level int32
+	tag   string
+
+	mu      sync.Mutex
+	partial []byte
+}
+
+func (w *commandWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	n := len(p)
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			w.partial = append(w.partial, p...)
+			for len(w.partial) >= maxCommandLine {
+				w.log(w.partial[:maxCommandLine])
+				w.partial = append(w.partial[:0], w.partial[maxCommandLine:]...)
+			}
+			break
+		}
+		line := p[:i]
+		if len(w.partial) > 0 {
+			w.partial = append(w.partial, line...)
+			line = w.partial
+		}
+		w.log(bytes.TrimSuffix(line, []byte("\r")))
+		w.partial = w.partial[:0]
+		p = p[i+1:]
+	}
+	return n, nil
+}
+
+// Close logs the partial line left, if any.
+func (w *commandWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.partial) > 0 {
+		w.log(w.partial)
+		w.partial = nil
+	}
+	return nil
+}
+
+func (w *commandWriter) log(line []byte) {
+	w.l.outputDepth(3, w.level, w.tag, "%s", line)
+}
@@ -0,0 +1,44 @@
+package golog
+
+import (
+	"os/exec"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestCommandWriter(t *testing.T) {
+	buf := captureGlobal(t, LEVEL_INFO)
+
+	cmd := exec.Command("sh", "-c", "echo a; echo b 1>&2")
+	stdout := CommandWriter(LEVEL_INFO, "sh")
+	stderr := CommandWriter(LEVEL_WARNING, "sh")
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	if err := cmd.Run(); err != nil {
+		t.Skipf("sh: %v", err)
+	}
+	stdout.Close()
+	stderr.Close()
+
+	got := buf.String()
+	if !regexp.MustCompile(`(?m)^`+headerRe+`\[INFO\] \S+: sh a$`).MatchString(got) ||
+		!regexp.MustCompile(`(?m)^`+headerRe+`\[WARNING\] \S+: sh b$`).MatchString(got) ||
+		strings.Count(got, "\n") != 2 {
+		t.Errorf("got:\n%s", got)
+	}
+
+	// partial lines are joined across writes, the rest is logged on Close.
+	buf.Reset()
+	w := CommandWriter(LEVEL_INFO, "tool")
+	w.Write([]byte("one\ntw"))
+	w.Write([]byte("o\r\nthr"))
+	if strings.Count(buf.String(), "\n") != 2 {
+		t.Errorf("partial line logged before its end:\n%s", buf.String())
+	}
+	w.Close()
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 3 || !strings.HasSuffix(lines[0], ": tool one") ||
+		!strings.HasSuffix(lines[1], ": tool two") || !strings.HasSuffix(lines[2], ": tool thr") {
+		t.Errorf("got:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,116 @@
+package golog
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync/atomic"
+)
+
+// compactFields is the number of struct fields Compact shows.
+const compactFields = 3
+
+var valueLimit atomic.Int32
+
+func init() {
+	valueLimit.Store(256)
+}
+
+// SetValueLimit sets the length, in bytes, Compact values are cut at.
+// The default is 256, n <= 0 means no limit.
+func SetValueLimit(n int) {
+	valueLimit.Store(int32(n))
+}
+
+// Compact wraps v, e.g. a large protobuf message, so that it is logged
+// as a bounded summary instead of in full:
+//
+//	golog.Debug("request %v", golog.Compact(req))
+//
+// A Stringer or error is logged with its String or Error output, other
+// values with their type and, for structs, the first few fields; both
+// are cut at the limit set by SetValueLimit. Nothing is done unless the
+// record is logged: formatting happens after the level check.
+func Compact(v interface{}) fmt.Formatter {
+	return compact{v}
+}
+
+type compact struct {
+	v interface{}
+}
+
+func (c compact) Format(f fmt.State, verb rune) {
+	var buf []byte
+	switch v := c.v.(type) {
+	case fmt.Stringer:
+		buf = append(buf, v.String()...)
+	case error:
+		buf = append(buf, v.Error()...)
+	default:
+		buf = appendSummary(buf, reflect.ValueOf(v), true)
+	}
+	if limit := int(valueLimit.Load()); limit > 0 && len(buf) > limit {
+		buf = append(buf[:limit], "..."...)
+	}
+	f.Write(buf)
+}
+
+// appendSummary appends v without descending into it: scalars in full,
+// containers as their type and length. With top set, struct fields are
+// summarized one level down.
+func appendSummary(buf []byte, v reflect.Value, top bool) []byte {
+	if !v.IsValid() {
+		return append(buf, "<nil>"...)
+	}
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return append(buf, "<nil>"...)
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		buf = append(buf, v.Type().String()...)
+		if !top {
+			return buf
+		}
+		buf = append(buf, '{')
+		t := v.Type()
+		shown := 0
+		for i := 0; i < t.NumField() && shown < compactFields; i++ {
+			if !t.Field(i).IsExported() {
+				continue
+			}
+			if shown > 0 {
+				buf = append(buf, ' ')
+			}
+			buf = append(buf, t.Field(i).Name...)
+			buf = append(buf, ':')
+			buf = appendSummary(buf, v.Field(i), false)
+			shown++
+		}
+		if shown < exportedFields(t) {
+			buf = append(buf, " ..."...)
+		}
+		return append(buf, '}')
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.Chan:
+		buf = append(buf, v.Type().String()...)
+		buf = append(buf, " len="...)
+		return strconv.AppendInt(buf, int64(v.Len()), 10)
+	case reflect.String:
+		return strconv.AppendQuote(buf, v.String())
+	case reflect.Func, reflect.UnsafePointer:
+		return append(buf, v.Type().String()...)
+	}
+	return fmt.Append(buf, v.Interface())
+}
+
+func exportedFields(t reflect.Type) int {
+	n := 0
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).IsExported() {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,59 @@
+package golog
+
+import (
+	"strings"
+	"testing"
+)
+
+type countingStringer struct {
+	calls *int
+	s     string
+}
+
+func (c countingStringer) String() string {
+	*c.calls++
+	return c.s
+}
+
+type leaf struct {
+	ID   int
+	Tags []string
+}
+
+type message struct {
+	Name     string
+	Size     int
+	Children []*leaf
+	Parent   *leaf
+	Extra    map[string]string
+	secret   string
+}
+
+func TestCompact(t *testing.T) {
+	buf := captureGlobal(t, LEVEL_INFO)
+	defer SetValueLimit(256)
+
+	calls := 0
+	Debug("disabled %v", Compact(countingStringer{&calls, "x"}))
+	if calls != 0 {
+		t.Errorf("String called %d times for a disabled level", calls)
+	}
+
+	SetValueLimit(10)
+	Info("stringer %v", Compact(countingStringer{&calls, strings.Repeat("y", 100)}))
+	if calls != 1 || !strings.HasSuffix(buf.String(), ": stringer yyyyyyyyyy...\n") {
+		t.Errorf("String called %d times, got %q", calls, buf.String())
+	}
+
+	buf.Reset()
+	SetValueLimit(0)
+	m := &message{Name: "big", Size: 3, Parent: &leaf{ID: 1}, secret: "s"}
+	for i := 0; i < 1000; i++ {
+		m.Children = append(m.Children, &leaf{ID: i, Tags: []string{"a", "b"}})
+	}
+	Info("msg %v", Compact(m))
+	want := `: msg golog.message{Name:"big" Size:3 Children:[]*golog.leaf len=1000 ...}` + "\n"
+	if !strings.HasSuffix(buf.String(), want) {
+		t.Errorf("got %q, want suffix %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,187 @@
+package golog
+
+import (
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// LoggerConfig is the configuration of a Logger in a form that can be
+// saved as JSON and applied again, e.g. on SIGHUP. Durations are
+// strings as understood by time.ParseDuration ("1h", "500ms").
+type LoggerConfig struct {
+	Level          Level  `json:"level"`               // a name, see Level
+	Verbosity      int32  `json:"verbosity,omitempty"` // SetVerbosity
+	File           string `json:"file,omitempty"`      // empty leaves the output alone
+	Compress       bool   `json:"compress,omitempty"`
+	LineTerminator string `json:"line_terminator,omitempty"`
+	MaxLines       int64  `json:"max_lines,omitempty"`
+	WriteTimeout   string `json:"write_timeout,omitempty"` // DropWithTimeout, empty means Block
+	RotatePeriod   string `json:"rotate_period,omitempty"` // EnableRotate
+	SaveTime       string `json:"save_time,omitempty"`     // SetFileMaxAge
+
+	Multiline          MultilineMode `json:"multiline,omitempty"`
+	ContinuationPrefix string        `json:"continuation_prefix,omitempty"`
+	Flags              int           `json:"flags,omitempty"` // L* header flags
+	QuoteMessages      bool          `json:"quote_messages,omitempty"`
+	HeaderStyle        HeaderStyle   `json:"header_style,omitempty"`
+	PathPrefix         string        `json:"path_prefix,omitempty"`
+}
+
+func MarshalConfig() (LoggerConfig, error) {
+	return std().MarshalConfig()
+}
+
+func ApplyConfig(cfg LoggerConfig) error {
+	return std().ApplyConfig(cfg)
+}
+
+// MarshalConfig returns the current configuration of l.
+func (l *Logger) MarshalConfig() (LoggerConfig, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	cfg := LoggerConfig{
+		Level:          Level(atomic.LoadInt32(&l.level)),
+		Verbosity:      atomic.LoadInt32(&l.verbosity),
+		File:           l.file.Path(),
+		Compress:       l.file != nil && l.file.compress,
+		LineTerminator: l.eol,
+		MaxLines:       l.maxLines,
+
+		Multiline:          l.multiline,
+		ContinuationPrefix: l.contPrefix,
+		Flags:              l.flags,
+		QuoteMessages:      l.quote,
+		HeaderStyle:        l.style,
+		PathPrefix:         l.pathPrefix,
+	}
+	if l.writeTimeout > 0 {
+		cfg.WriteTimeout = l.writeTimeout.String()
+	}
+	if l.rotatePeriod > 0 {
+		cfg.RotatePeriod = l.rotatePeriod.String()
+	}
+	if l.saveTime > 0 {
+		cfg.SaveTime = l.saveTime.String()
+	}
+	return cfg, nil
+}
+
+// ApplyConfig checks cfg and, if it is valid, switches l over to it in
+// one step: concurrent log calls see either the old or the new
+// configuration, and no rotation runs on the old schedule after the
+// switch. Rotation can be turned on or its period changed, but not
+// turned off; rotation and save time only apply to the global logger.
+// A new file is opened with the permissions of the current one.
+func (l *Logger) ApplyConfig(cfg LoggerConfig) error {
+	// LEVEL_EMERGENCY-1 is off, see NewDiscardLogger.
+	if cfg.Level < LEVEL_EMERGENCY-1 || cfg.Level > LEVEL_VERBOSE {
+		return fmt.Errorf("golog: bad level %d", cfg.Level)
+	}
+	switch cfg.LineTerminator {
+	case "", "\n", "\r\n", "\x00":
+	default:
+		return fmt.Errorf("golog: bad line terminator %q", cfg.LineTerminator)
+	}
+	if cfg.Multiline < MultilineKeep || cfg.Multiline > MultilinePrefix {
+		return fmt.Errorf("golog: bad multiline mode %d", cfg.Multiline)
+	}
+	if cfg.HeaderStyle < StyleGolog || cfg.HeaderStyle > StyleStdlib {
+		return fmt.Errorf("golog: bad header style %d", cfg.HeaderStyle)
+	}
+	writeTimeout, err := parseConfigDuration("write_timeout", cfg.WriteTimeout)
+	if err != nil {
+		return err
+	}
+	rotatePeriod, err := parseConfigDuration("rotate_period", cfg.RotatePeriod)
+	if err != nil {
+		return err
+	}
+	if rotatePeriod > 0 && (rotatePeriod < time.Minute || rotatePeriod%time.Minute != 0) {
+		return fmt.Errorf("golog: bad rotate period %v, want a whole number of minutes", rotatePeriod)
+	}
+	keep, err := parseConfigDuration("save_time", cfg.SaveTime)
+	if err != nil {
+		return err
+	}
+	if l != std() && rotatePeriod > 0 {
+		return fmt.Errorf("golog: rotation is only supported on the global logger")
+	}
+
+	l.mu.RLock()
+	changeRotation := rotatePeriod > 0 && rotatePeriod != l.rotatePeriod
+	perm := defaultPerm
+	if l.file != nil {
+		perm = l.file.perm
+	}
+	hasFile := l.file != nil
+	l.mu.RUnlock()
+	if changeRotation && cfg.File == "" && !hasFile {
+		return errNoFile
+	}
+
+	// open the new file before taking the lock, and give up before
+	// changing anything if that fails.
+	var file *FileSink
+	if cfg.File != "" {
+		file, err = openFileSink(cfg.File, cfg.Compress, perm)
+		if err != nil {
+			return err
+		}
+	}
+
+	// hold off the rotator until it is on the new schedule.
+	var r *rotator
+	if changeRotation {
+		r = l.getRotator()
+		r.mu.Lock()
+		defer r.mu.Unlock()
+	}
+
+	l.mu.Lock()
+	var old []io.Closer
+	if file != nil {
+		old = l.detach()
+		l.out = fileWriter{file}
+		l.file = file
+		l.closed = false
+		l.replayEarly()
+	}
+	l.setLevel(int32(cfg.Level))
+	atomic.StoreInt32(&l.verbosity, cfg.Verbosity)
+	l.eol = cfg.LineTerminator
+	if cfg.MaxLines != l.maxLines {
+		l.maxLines = cfg.MaxLines
+		l.lines = 0
+	}
+	l.setWriteTimeout(writeTimeout)
+	l.saveTime = keep
+	l.multiline = cfg.Multiline
+	l.contPrefix = cfg.ContinuationPrefix
+	l.flags = cfg.Flags
+	l.quote = cfg.QuoteMessages
+	l.style = cfg.HeaderStyle
+	l.pathPrefix = cfg.PathPrefix
+	l.mu.Unlock()
+
+	l.retire(old)
+
+	if r != nil {
+		next, suffix := periodSchedule(rotatePeriod)
+		r.retargetLocked(rotatePeriod, next, suffix)
+	}
+	return nil
+}
+
+func parseConfigDuration(name, s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("golog: bad %s %q", name, s)
+	}
+	return d, nil
+}
@@ -0,0 +1,107 @@
+package golog
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConfigRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	l := NewDiscardLogger()
+	cfg := LoggerConfig{
+		Level:          LEVEL_DEBUG,
+		Verbosity:      2,
+		File:           path,
+		LineTerminator: "\r\n",
+		MaxLines:       1000,
+		WriteTimeout:   "50ms",
+
+		Multiline:          MultilinePrefix,
+		ContinuationPrefix: "| ",
+		Flags:              Lpackagefile,
+		QuoteMessages:      true,
+		HeaderStyle:        StyleStdlib,
+		PathPrefix:         "/src/",
+	}
+	if err := l.ApplyConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	defer l.out.(io.Closer).Close()
+	l.SetWritePolicy(Block)
+	l.output(LEVEL_DEBUG, "configured")
+
+	data, _ := os.ReadFile(path)
+	if !strings.HasSuffix(string(data), ": \"configured\"\r\n") {
+		t.Fatalf("unexpected file contents %q", data)
+	}
+
+	cfg.WriteTimeout = ""
+	got, err := l.MarshalConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, cfg) {
+		t.Fatalf("got %+v, want %+v", got, cfg)
+	}
+
+	js, err := json.Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back LoggerConfig
+	if err := json.Unmarshal(js, &back); err != nil || !reflect.DeepEqual(back, cfg) {
+		t.Fatalf("json round trip %s: %+v, %v", js, back, err)
+	}
+}
+
+func TestApplyConfigInvalid(t *testing.T) {
+	l := NewDiscardLogger()
+	for _, cfg := range []LoggerConfig{
+		{Level: 42},
+		{Level: LEVEL_INFO, LineTerminator: "\r"},
+		{Level: LEVEL_INFO, WriteTimeout: "soon"},
+		{Level: LEVEL_INFO, RotatePeriod: "90s"},
+		{Level: LEVEL_INFO, RotatePeriod: "1h"}, // not the global logger
+		{Level: LEVEL_INFO, File: filepath.Join(t.TempDir(), "missing", "app.log")},
+		{Level: LEVEL_INFO, Multiline: MultilinePrefix + 1},
+		{Level: LEVEL_INFO, HeaderStyle: -1},
+	} {
+		if err := l.ApplyConfig(cfg); err == nil {
+			t.Errorf("accepted %+v", cfg)
+		}
+	}
+	if l.level != LEVEL_EMERGENCY-1 || l.eol != "" {
+		t.Fatal("invalid config was partly applied")
+	}
+}
+
+func TestApplyConfigKeepsPerm(t *testing.T) {
+	dir := t.TempDir()
+	l := NewDiscardLogger()
+	if err := l.ApplyConfig(LoggerConfig{Level: LEVEL_INFO, File: filepath.Join(dir, "a.log")}); err != nil {
+		t.Fatal(err)
+	}
+	l.file.perm = 0600
+	old := l.file
+	path := filepath.Join(dir, "b.log")
+	if err := l.ApplyConfig(LoggerConfig{Level: LEVEL_INFO, File: path}); err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if _, err := (fileWriter{old}).Write([]byte("x")); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("replaced file still open: %v", err)
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := fi.Mode().Perm(); perm&^0600 != 0 {
+		t.Errorf("new file has mode %v, want 0600", perm)
+	}
+}
@@ -0,0 +1,52 @@
+package golog
+
+import (
+	"context"
+)
+
+type prefixKey struct{}
+
+// ContextWithLogPrefix returns a copy of ctx carrying prefix; the *CtxP
+// functions put it in front of every message logged with that context,
+// e.g. a request id.
+func ContextWithLogPrefix(ctx context.Context, prefix string) context.Context {
+	return context.WithValue(ctx, prefixKey{}, prefix)
+}
+
+// LogPrefixFromContext returns the prefix stored by ContextWithLogPrefix,
+// or "" if there is none.
+func LogPrefixFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	prefix, _ := ctx.Value(prefixKey{}).(string)
+	return prefix
+}
+
+func CriticalCtxP(ctx context.Context, format string, v ...interface{}) {
+	std().outputDepth(2, LEVEL_CRITICAL, LogPrefixFromContext(ctx), format, v...)
+}
+
+func ErrorCtxP(ctx context.Context, format string, v ...interface{}) {
+	std().outputDepth(2, LEVEL_ERROR, LogPrefixFromContext(ctx), format, v...)
+}
+
+func WarnCtxP(ctx context.Context, format string, v ...interface{}) {
+	std().outputDepth(2, LEVEL_WARNING, LogPrefixFromContext(ctx), format, v...)
+}
+
+func NoticeCtxP(ctx context.Context, format string, v ...interface{}) {
+	std().outputDepth(2, LEVEL_NOTICE, LogPrefixFromContext(ctx), format, v...)
+}
+
+func InfoCtxP(ctx context.Context, format string, v ...interface{}) {
+	std().outputDepth(2, LEVEL_INFO, LogPrefixFromContext(ctx), format, v...)
+}
+
+func DebugCtxP(ctx context.Context, format string, v ...interface{}) {
+	std().outputDepth(2, LEVEL_DEBUG, LogPrefixFromContext(ctx), format, v...)
+}
+
+func VerboseCtxP(ctx context.Context, format string, v ...interface{}) {
+	std().outputDepth(2, LEVEL_VERBOSE, LogPrefixFromContext(ctx), format, v...)
+}
@@ -0,0 +1,23 @@
+package golog
+
+import (
+	"context"
+	"regexp"
+	"testing"
+)
+
+func TestCtxPrefix(t *testing.T) {
+	buf := captureGlobal(t, LEVEL_INFO)
+
+	ctx := ContextWithLogPrefix(context.Background(), "req-42")
+	InfoCtxP(ctx, "handled %s", "/index")
+	ErrorCtxP(context.Background(), "no prefix")
+	DebugCtxP(ctx, "hidden")
+
+	re := regexp.MustCompile(`^` +
+		headerRe + `\[INFO\] context_test.go:\d+: req-42 handled /index\n` +
+		headerRe + `\[ERROR\] context_test.go:\d+: no prefix\n$`)
+	if !re.Match(buf.Bytes()) {
+		t.Fatalf("unexpected output:\n%s", buf)
+	}
+}
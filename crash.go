@@ -0,0 +1,117 @@
+package golog
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+// room reserved by EnableCrashDump for a dump, the stack traces take
+// most of it.
+const crashBufSize = 1 << 20
+
+// crashDump is what EnableCrashDump sets up ahead of time, so writing
+// the dump needs neither opening files nor, mostly, allocating.
+type crashDump struct {
+	f   *os.File
+	buf []byte
+}
+
+var crash atomic.Pointer[crashDump]
+
+// EnableCrashDump makes the process write its last words to path when
+// it dies of a panic caught by CrashGuard or Main, or of SIGABRT or
+// SIGSEGV sent to it (on Windows, of a panic only): the panic value or
+// signal, the records kept by a RingLogger added with AddSink, the
+// records still queued by DropWithTimeout, and the stack traces. The
+// dump is appended to path, written with O_SYNC; then the panic goes on,
+// or the signal is raised again with its default action.
+//
+// Go turns a SIGSEGV caused by the program itself into a panic, which
+// only CrashGuard sees.
+func EnableCrashDump(path string) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND|os.O_SYNC, 0666)
+	if err != nil {
+		return err
+	}
+	if old := crash.Swap(&crashDump{f: f, buf: make([]byte, 0, crashBufSize)}); old != nil {
+		old.f.Close()
+	}
+	notifyCrashSignals()
+	return nil
+}
+
+// CrashGuard writes the crash dump for a panic and lets it go on. It
+// must be deferred directly, at the top of main or of a goroutine:
+//
+//	defer golog.CrashGuard()
+func CrashGuard() {
+	v := recover()
+	if v == nil {
+		return
+	}
+	writeCrash("panic", v, false)
+	panic(v)
+}
+
+// Main runs f under CrashGuard.
+func Main(f func()) {
+	defer CrashGuard()
+	f()
+}
+
+// writeCrash writes the dump, what is "panic" or "signal". Locks are
+// only tried: the crash may have happened while one was held.
+func writeCrash(what string, v interface{}, allGoroutines bool) {
+	c := crash.Swap(nil)
+	if c == nil {
+		return
+	}
+	buf := c.buf[:0]
+	buf = append(buf, "=== golog crash dump "...)
+	buf = time.Now().AppendFormat(buf, time.RFC3339Nano)
+	buf = append(buf, " ===\n"...)
+	buf = append(buf, what...)
+	buf = append(buf, ": "...)
+	buf = fmt.Append(buf, v)
+
+	var sinks []Sink
+	var q chan queuedRecord
+	l := std()
+	if l.mu.TryRLock() {
+		sinks, q = l.allSinks(), l.queue
+		l.mu.RUnlock()
+	}
+	buf = append(buf, "\n--- recent records ---\n"...)
+	for _, s := range sinks {
+		if r, ok := s.(*RingLogger); ok {
+			buf = r.appendTo(buf)
+		}
+	}
+	buf = append(buf, "--- queued records ---\n"...)
+	if q != nil {
+	drain:
+		for {
+			select {
+			case rec := <-q:
+				buf = append(buf, rec.rec...)
+			default:
+				break drain
+			}
+		}
+	}
+
+	buf = append(buf, "--- stack ---\n"...)
+	for {
+		n := runtime.Stack(buf[len(buf):cap(buf)], allGoroutines)
+		if len(buf)+n < cap(buf) {
+			buf = buf[:len(buf)+n]
+			break
+		}
+		buf = append(buf[:cap(buf)], 0)[:len(buf)]
+	}
+	c.f.Write(buf)
+	c.f.Close()
+}
@@ -0,0 +1,59 @@
+package golog
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCrashDump(t *testing.T) {
+	if path := os.Getenv("GOLOG_CRASH_DUMP"); path != "" {
+		crashingMain(path)
+		return
+	}
+
+	path := filepath.Join(t.TempDir(), "crash.log")
+	cmd := exec.Command(os.Args[0], "-test.run=^TestCrashDump$")
+	cmd.Env = append(os.Environ(), "GOLOG_CRASH_DUMP="+path)
+	out, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Fatalf("child did not crash:\n%s", out)
+	}
+	if !strings.Contains(string(out), "panic: disk on fire") {
+		t.Errorf("panic did not go on:\n%s", out)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dump := string(data)
+	for _, want := range []string{
+		"=== golog crash dump ",
+		"panic: disk on fire\n--- recent records ---\n",
+		"[INFO] crash_test.go:",
+		": starting\n",
+		": about to crash\n--- queued records ---\n--- stack ---\ngoroutine ",
+		".crashingMain(",
+	} {
+		if !strings.Contains(dump, want) {
+			t.Errorf("missing %q in dump:\n%s", want, dump)
+		}
+	}
+}
+
+func crashingMain(path string) {
+	if err := EnableCrashDump(path); err != nil {
+		panic(err)
+	}
+	r, _ := NewRingLogger(10)
+	AddSink(r)
+	SetLevel(LEVEL_INFO)
+	Main(func() {
+		Info("starting")
+		Info("about to crash")
+		panic("disk on fire")
+	})
+}
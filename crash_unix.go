@@ -0,0 +1,25 @@
+//go:build !windows
+
+package golog
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+var crashSignalsOnce sync.Once
+
+func notifyCrashSignals() {
+	crashSignalsOnce.Do(func() {
+		c := make(chan os.Signal, 1)
+		signal.Notify(c, syscall.SIGABRT, syscall.SIGSEGV)
+		go func() {
+			sig := <-c
+			writeCrash("signal", sig, true)
+			signal.Reset(sig)
+			syscall.Kill(os.Getpid(), sig.(syscall.Signal))
+		}()
+	})
+}
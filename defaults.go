@@ -0,0 +1,54 @@
+package golog
+
+import (
+	"fmt"
+	"sort"
+)
+
+// SetDefaultFields sets fields added as key=value pairs to every record
+// of the global logger, e.g. the service name and version. See
+// Logger.SetDefaultFields.
+func SetDefaultFields(fields map[string]interface{}) {
+	std().SetDefaultFields(fields)
+}
+
+// SetDefaultFields sets fields added as key=value pairs, in key order,
+// after the message of every record l writes. Scope fields, see
+// WithScope and WithError, take precedence over default fields with the
+// same key. nil removes the
+// default fields.
+func (l *Logger) SetDefaultFields(fields map[string]interface{}) {
+	defaults := make([]field, 0, len(fields))
+	for k, v := range fields {
+		defaults = append(defaults, field{k, v})
+	}
+	sort.Slice(defaults, func(i, j int) bool { return defaults[i].key < defaults[j].key })
+	text := appendDefaults(nil, defaults, nil)
+
+	l = l.root()
+	l.mu.Lock()
+	l.defaults = defaults
+	l.defaultsText = string(text)
+	l.mu.Unlock()
+}
+
+// appendContext appends the scope fields sc, see WithScope and PushScope,
+// and the default fields, l.mu must be held.
+func (l *Logger) appendContext(buf []byte, sc []*field) []byte {
+	if sc == nil {
+		return append(buf, l.defaultsText...)
+	}
+	buf = appendScope(buf, sc)
+	return appendDefaults(buf, l.defaults, func(key string) bool { return scopeHas(sc, key) })
+}
+
+// appendDefaults appends the fields, except those for which skip returns
+// true.
+func appendDefaults(buf []byte, fields []field, skip func(key string) bool) []byte {
+	for _, f := range fields {
+		if skip == nil || !skip(f.key) {
+			buf = fmt.Appendf(buf, " %s=%v", f.key, f.value)
+		}
+	}
+	return buf
+}
@@ -0,0 +1,28 @@
+package golog
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+)
+
+func TestSetDefaultFields(t *testing.T) {
+	buf := captureGlobal(t, LEVEL_INFO)
+
+	SetDefaultFields(map[string]interface{}{"service": "billing", "version": "1.4.2", "env": "prod"})
+	Info("started")
+	WithError(errors.New("timeout")).With("env", "canary").Warn("retry")
+	child := GetGlobalLogger().AddCallerSkipFrames(0)
+	child.Notice("from child")
+	SetDefaultFields(nil)
+	Info("plain")
+
+	re := regexp.MustCompile(`^` +
+		headerRe + `\[INFO\] defaults_test.go:\d+: started env=prod service=billing version=1.4.2\n` +
+		headerRe + `\[WARNING\] defaults_test.go:\d+: retry error="timeout" error_type=\*errors.errorString env=canary service=billing version=1.4.2\n` +
+		headerRe + `\[NOTICE\] defaults_test.go:\d+: from child env=prod service=billing version=1.4.2\n` +
+		headerRe + `\[INFO\] defaults_test.go:\d+: plain\n$`)
+	if !re.MatchString(buf.String()) {
+		t.Errorf("got:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,49 @@
+package golog
+
+import (
+	"io"
+	"reflect"
+	"sync"
+)
+
+// destLocks serialize the writes to a writer shared by several loggers,
+// e.g. those of a MultiLogger: every record gets there in one Write,
+// finished before the next one starts, even if the writer splits it (a
+// pipe takes 4KiB at a time). It maps each writer to its own
+// *sync.Mutex, so a slow writer holds up only the loggers writing to it.
+// The lock of a writer is made when it is set as an output and kept from
+// then on.
+var destLocks sync.Map
+
+// destLock returns the lock writes to w are made under. A FileSink and a
+// Logger serialize their writes themselves, and a writer with Lock and
+// Unlock methods is its own lock. A writer that is not comparable, so has
+// no identity to share a lock by, is written unlocked; it can not be
+// shared anyway, every logger has its own copy.
+func destLock(w io.Writer) sync.Locker {
+	switch w := w.(type) {
+	case nil, fileWriter, *Logger:
+		return nil
+	case sync.Locker:
+		return w
+	}
+	if !reflect.TypeOf(w).Comparable() {
+		return nil
+	}
+	if mu, ok := destLocks.Load(w); ok {
+		return mu.(*sync.Mutex)
+	}
+	mu, _ := destLocks.LoadOrStore(w, new(sync.Mutex))
+	return mu.(*sync.Mutex)
+}
+
+// writeDest writes rec to w in one Write, under the lock of w.
+func writeDest(w io.Writer, rec []byte) (int, error) {
+	mu := destLock(w)
+	if mu == nil {
+		return w.Write(rec)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	return w.Write(rec)
+}
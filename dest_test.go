@@ -0,0 +1,128 @@
+package golog
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// chunkWriter writes in small pieces, like a pipe taking a record larger
+// than its buffer, giving other writers a chance in between. It is not
+// safe for concurrent use, lockedChunkWriter is.
+type chunkWriter struct {
+	w     io.Writer
+	chunk int
+}
+
+func (c *chunkWriter) Write(p []byte) (int, error) {
+	n := 0
+	for len(p) > 0 {
+		m := min(c.chunk, len(p))
+		k, err := c.w.Write(p[:m])
+		n += k
+		if err != nil {
+			return n, err
+		}
+		p = p[m:]
+		runtime.Gosched()
+	}
+	return n, nil
+}
+
+type lockedChunkWriter struct {
+	sync.Mutex
+	chunkWriter
+}
+
+func TestRecordAtomicity(t *testing.T) {
+	t.Run("pipe", func(t *testing.T) {
+		testRecordAtomicity(t, func(w *os.File) io.Writer { return w })
+	})
+	t.Run("locker", func(t *testing.T) {
+		testRecordAtomicity(t, func(w *os.File) io.Writer {
+			return &lockedChunkWriter{chunkWriter: chunkWriter{w: w, chunk: 512}}
+		})
+	})
+	t.Run("unlocked writer", func(t *testing.T) {
+		testRecordAtomicity(t, func(w *os.File) io.Writer { return &chunkWriter{w: w, chunk: 512} })
+	})
+}
+
+// testRecordAtomicity writes 10KB records from 50 goroutines to a pipe
+// behind the writer returned by out, through five loggers, and checks
+// that no records interleave and that the records of each goroutine come
+// in order.
+func testRecordAtomicity(t *testing.T, out func(w *os.File) io.Writer) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines []string
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s := bufio.NewScanner(r)
+		for s.Scan() {
+			lines = append(lines, s.Text())
+		}
+	}()
+
+	dest := out(w)
+	var loggers []*Logger
+	for i := 0; i < 5; i++ {
+		loggers = append(loggers, &Logger{out: dest, level: LEVEL_INFO})
+	}
+	const goroutines, records = 50, 10
+	body := strings.Repeat("x", 96)
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			l := loggers[g%len(loggers)]
+			for i := 0; i < records; i++ {
+				id := fmt.Sprintf("%02d-%02d", g, i)
+				var rec strings.Builder
+				rec.WriteString("begin " + id)
+				for j := 0; j < 100; j++ {
+					rec.WriteString("\n" + id + " " + body)
+				}
+				rec.WriteString("\nend " + id)
+				l.Info("%s", rec.String())
+			}
+		}(g)
+	}
+	wg.Wait()
+	w.Close()
+	<-done
+
+	if len(lines) != goroutines*records*102 {
+		t.Fatalf("%d lines, want %d", len(lines), goroutines*records*102)
+	}
+	next := make([]int, goroutines)
+	for i := 0; i < len(lines); i += 102 {
+		_, id, ok := strings.Cut(lines[i], ": begin ")
+		if !ok {
+			t.Fatalf("line %d does not start a record: %.60q", i, lines[i])
+		}
+		for j := 1; j <= 100; j++ {
+			if lines[i+j] != id+" "+body {
+				t.Fatalf("record %s interleaved at line %d: %.60q", id, i+j, lines[i+j])
+			}
+		}
+		if lines[i+101] != "end "+id {
+			t.Fatalf("record %s not terminated: %.60q", id, lines[i+101])
+		}
+		var g, seq int
+		fmt.Sscanf(id, "%d-%d", &g, &seq)
+		if seq != next[g] {
+			t.Fatalf("record %s out of order, want %02d-%02d", id, g, next[g])
+		}
+		next[g]++
+	}
+}
@@ -0,0 +1,138 @@
+// Package diff compares golog records, e.g. the same record from two
+// runs of a program when looking for a regression:
+//
+//	for k, v := range diff.DiffEntries(before, after) {
+//		fmt.Println(k, v)
+//	}
+package diff
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/idning/golog"
+)
+
+// DiffEntries returns the parts of a and b that differ, mapped to
+// []interface{}{old, new}:
+//
+//   - "time" and "level", from the Entry fields
+//   - "caller", the file:line of the header
+//   - "message", the text of the message without its key=value fields,
+//     and "message_diff", a word diff of the two, marking words only in a
+//     as [-word-] and words only in b as {+word+}
+//   - every key=value field of the message, by key; a field only one
+//     side has is nil on the other
+//
+// Identical entries give an empty map.
+func DiffEntries(a, b golog.Entry) map[string]interface{} {
+	d := map[string]interface{}{}
+	if !a.Time.Equal(b.Time) {
+		d["time"] = []interface{}{a.Time, b.Time}
+	}
+	if a.Level != b.Level {
+		d["level"] = []interface{}{a.Level, b.Level}
+	}
+
+	ra, rb := parse(a.Line), parse(b.Line)
+	if ra.caller != rb.caller {
+		d["caller"] = []interface{}{ra.caller, rb.caller}
+	}
+	if ma, mb := strings.Join(ra.words, " "), strings.Join(rb.words, " "); ma != mb {
+		d["message"] = []interface{}{ma, mb}
+		d["message_diff"] = wordDiff(ra.words, rb.words)
+	}
+	for k, va := range ra.fields {
+		if vb, ok := rb.fields[k]; !ok {
+			d[k] = []interface{}{va, nil}
+		} else if va != vb {
+			d[k] = []interface{}{va, vb}
+		}
+	}
+	for k, vb := range rb.fields {
+		if _, ok := ra.fields[k]; !ok {
+			d[k] = []interface{}{nil, vb}
+		}
+	}
+	return d
+}
+
+type record struct {
+	caller string
+	words  []string          // message words, fields left out
+	fields map[string]string // key=value fields, quoted values unquoted
+}
+
+// parse splits a formatted line, "2015-05-14 09:56:00.023132 [INFO]
+// file.go:12: message key=value", into its caller, message words and
+// fields. Lines without a header are all message.
+func parse(line string) record {
+	r := record{fields: map[string]string{}}
+	line = strings.TrimRight(line, "\r\n")
+	if _, rest, ok := strings.Cut(line, "] "); ok {
+		line = rest
+	}
+	if caller, msg, ok := strings.Cut(line, ": "); ok && !strings.Contains(caller, " ") {
+		r.caller, line = caller, msg
+	}
+
+	words := strings.Fields(line)
+	for i := 0; i < len(words); i++ {
+		w := words[i]
+		k, v, ok := strings.Cut(w, "=")
+		if !ok || k == "" || strings.ContainsAny(k, `"'[]()`) {
+			r.words = append(r.words, w)
+			continue
+		}
+		if strings.HasPrefix(v, `"`) {
+			// a quoted value may have spaces, take words until it ends.
+			for j := i; j < len(words); j++ {
+				q := strings.Join(words[i:j+1], " ")
+				if s, err := strconv.Unquote(q[len(k)+1:]); err == nil {
+					v, i = s, j
+					break
+				}
+			}
+		}
+		r.fields[k] = v
+	}
+	return r
+}
+
+// wordDiff returns the words of a and b, those only in a marked [-word-]
+// and those only in b marked {+word+}, after a longest common
+// subsequence.
+func wordDiff(a, b []string) string {
+	// lcs[i][j] is the LCS length of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var out []string
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			out = append(out, a[i])
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			out = append(out, "[-"+a[i]+"-]")
+			i++
+		default:
+			out = append(out, "{+"+b[j]+"+}")
+			j++
+		}
+	}
+	return strings.Join(out, " ")
+}
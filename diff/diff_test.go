@@ -0,0 +1,34 @@
+package diff
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/idning/golog"
+)
+
+func TestDiffEntries(t *testing.T) {
+	t0 := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+	a := golog.Entry{Time: t0, Level: golog.LEVEL_INFO,
+		Line: "2026-10-15 09:00:00.000000 [INFO] server.go:42: request done in 12ms status=200 path=/users\n"}
+	b := golog.Entry{Time: t0.Add(time.Hour), Level: golog.LEVEL_WARNING,
+		Line: "2026-10-15 10:00:00.000000 [WARNING] server.go:45: request slow done in 340ms status=200 error=\"deadline exceeded\" user=7\n"}
+
+	want := map[string]interface{}{
+		"time":         []interface{}{a.Time, b.Time},
+		"level":        []interface{}{a.Level, b.Level},
+		"caller":       []interface{}{"server.go:42", "server.go:45"},
+		"message":      []interface{}{"request done in 12ms", "request slow done in 340ms"},
+		"message_diff": "request {+slow+} done in [-12ms-] {+340ms+}",
+		"path":         []interface{}{"/users", nil},
+		"error":        []interface{}{nil, "deadline exceeded"},
+		"user":         []interface{}{nil, "7"},
+	}
+	if got := DiffEntries(a, b); !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffEntries() =\n%v\nwant\n%v", got, want)
+	}
+	if got := DiffEntries(a, a); len(got) != 0 {
+		t.Errorf("DiffEntries(a, a) = %v", got)
+	}
+}
@@ -0,0 +1,174 @@
+package golog
+
+import (
+	"errors"
+	"fmt"
+)
+
+type field struct {
+	key   string
+	value interface{}
+}
+
+// An ErrorEntry carries an error and fields until the level to log them
+// at is known:
+//
+//	e := golog.WithError(err).With("user", id)
+//	...
+//	e.Error("save failed")
+//
+// logs `save failed error="..." error_type=*fs.PathError user=42` and, if
+// err wraps other errors, their messages as error_chain. The fields are
+// scope fields of the record, see WithScope, and only built once the
+// level is known to be enabled, so a disabled entry does not allocate.
+// ErrorEntry is a value: With returns a new entry and leaves its receiver
+// alone, so an entry can be extended and logged any number of times.
+// With a nil error it logs just the message and fields.
+type ErrorEntry struct {
+	l      *Logger // nil is the global logger
+	err    error
+	n      int
+	inline [4]field // the first fields, so short entries do not allocate
+	more   []field
+}
+
+// WithError starts an ErrorEntry for err.
+func WithError(err error) ErrorEntry {
+	return ErrorEntry{err: err}
+}
+
+// WithError starts an ErrorEntry for err, logged to l.
+func (l *Logger) WithError(err error) ErrorEntry {
+	return ErrorEntry{l: l, err: err}
+}
+
+// With returns a copy of e with key=value added.
+func (e ErrorEntry) With(key string, value interface{}) ErrorEntry {
+	if e.n < len(e.inline) {
+		e.inline[e.n] = field{key, value}
+		e.n++
+		return e
+	}
+	// copy, another entry may share the backing array.
+	e.more = append(e.more[:len(e.more):len(e.more)], field{key, value})
+	return e
+}
+
+// withPairs returns a copy of e with fields given as alternating keys and
+// values added. A trailing key without a value gets "MISSING".
+func (e ErrorEntry) withPairs(kv []interface{}) ErrorEntry {
+	for i := 0; i < len(kv); i += 2 {
+		var value interface{} = "MISSING"
+		if i+1 < len(kv) {
+			value = kv[i+1]
+		}
+		e = e.With(fmt.Sprint(kv[i]), value)
+	}
+	return e
+}
+
+// Logger returns a child of the logger of e that adds the fields of e to
+// every record it logs, to pass the error on, e.g. to a function taking
+// a *Logger.
+func (e ErrorEntry) Logger() *Logger {
+	l := e.l
+	if l == nil {
+		l = std()
+	}
+	return l.withFields(e.fields()...)
+}
+
+// fields returns the error fields, then the fields added with With.
+func (e *ErrorEntry) fields() []*field {
+	fields := make([]*field, 0, 3+e.n+len(e.more))
+	if e.err != nil {
+		fields = append(fields,
+			&field{"error", fmt.Sprintf("%q", e.err.Error())},
+			&field{"error_type", fmt.Sprintf("%T", e.err)})
+		var chain []string
+		for err := errors.Unwrap(e.err); err != nil; err = errors.Unwrap(err) {
+			chain = append(chain, err.Error())
+		}
+		if chain != nil {
+			fields = append(fields, &field{"error_chain", fmt.Sprintf("%q", chain)})
+		}
+	}
+	for _, f := range e.inline[:e.n] {
+		fields = append(fields, &f)
+	}
+	for _, f := range e.more {
+		fields = append(fields, &f)
+	}
+	return fields
+}
+
+// output must be called directly by the exported methods of ErrorEntry.
+func (e ErrorEntry) output(level int32, format string, v []interface{}) {
+	if e.l == nil {
+		e.l = std()
+	}
+	if !e.l.enabledAt(2, level) {
+		return
+	}
+	e.log(4, level, format, v)
+}
+
+// log writes the record once the level check passed; the fields are
+// only built here.
+func (e *ErrorEntry) log(calldepth int, level int32, format string, v []interface{}) {
+	e.l.outputRecord(calldepth, level, "", e.fields(), 0, format, v...)
+}
+
+func (e ErrorEntry) Critical(format string, v ...interface{}) {
+	e.output(LEVEL_CRITICAL, format, v)
+}
+
+func (e ErrorEntry) Error(format string, v ...interface{}) {
+	e.output(LEVEL_ERROR, format, v)
+}
+
+func (e ErrorEntry) Warn(format string, v ...interface{}) {
+	e.output(LEVEL_WARNING, format, v)
+}
+
+func (e ErrorEntry) Notice(format string, v ...interface{}) {
+	e.output(LEVEL_NOTICE, format, v)
+}
+
+func (e ErrorEntry) Info(format string, v ...interface{}) {
+	e.output(LEVEL_INFO, format, v)
+}
+
+func (e ErrorEntry) Debug(format string, v ...interface{}) {
+	e.output(LEVEL_DEBUG, format, v)
+}
+
+func (e ErrorEntry) Verbose(format string, v ...interface{}) {
+	e.output(LEVEL_VERBOSE, format, v)
+}
+
+// ErrorW logs msg at LEVEL_ERROR with err, as WithError does, and fields
+// as alternating keys and values:
+//
+//	golog.ErrorW("connect failed", err, "host", host, "attempt", n)
+//
+// msg is not a format string, so the error cannot be lost to a %w that
+// fmt does not support.
+func ErrorW(msg string, err error, fields ...interface{}) {
+	std().errorW(msg, err, fields)
+}
+
+// ErrorW logs msg at LEVEL_ERROR to l, see the ErrorW function.
+func (l *Logger) ErrorW(msg string, err error, fields ...interface{}) {
+	l.errorW(msg, err, fields)
+}
+
+// errorW must be called directly by the exported ErrorW functions; the
+// fields are not even paired up before the level check.
+func (l *Logger) errorW(msg string, err error, fields []interface{}) {
+	if !l.enabledAt(2, LEVEL_ERROR) {
+		return
+	}
+	e := l.WithError(err).withPairs(fields)
+	e.log(4, LEVEL_ERROR, "%s", []interface{}{msg})
+}
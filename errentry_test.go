@@ -0,0 +1,120 @@
+package golog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestWithError(t *testing.T) {
+	buf := captureGlobal(t, LEVEL_INFO)
+
+	root := errors.New("permission denied")
+	err := fmt.Errorf("save user: %w", root)
+	e := WithError(err).With("user", 42)
+	e.Error("save %s", "failed")
+	e.With("retry", true).Warn("retrying")
+	e.Debug("hidden")
+	WithError(nil).With("user", 7).Info("plain")
+
+	re := regexp.MustCompile(`^` +
+		headerRe + `\[ERROR\] errentry_test.go:\d+: save failed error="save user: permission denied" error_type=\*fmt.wrapError error_chain=\["permission denied"\] user=42\n` +
+		headerRe + `\[WARNING\] errentry_test.go:\d+: retrying error="save user: permission denied" error_type=\*fmt.wrapError error_chain=\["permission denied"\] user=42 retry=true\n` +
+		headerRe + `\[INFO\] errentry_test.go:\d+: plain user=7\n$`)
+	if !re.MatchString(buf.String()) {
+		t.Errorf("got:\n%s", buf.String())
+	}
+}
+
+func TestLoggerWithError(t *testing.T) {
+	global := captureGlobal(t, LEVEL_INFO)
+	var buf bytes.Buffer
+	l := &Logger{out: &buf, level: LEVEL_INFO, microseconds: true}
+
+	l.WithError(errors.New("timeout")).With("peer", "db1").Warn("ping")
+	re := regexp.MustCompile(`^` + headerRe + `\[WARNING\] errentry_test.go:\d+: ping error="timeout" error_type=\*errors.errorString peer=db1\n$`)
+	if !re.MatchString(buf.String()) || global.Len() != 0 {
+		t.Errorf("got %q, global logger got %q", buf.String(), global.String())
+	}
+}
+
+func TestWithErrorManyFields(t *testing.T) {
+	buf := captureGlobal(t, LEVEL_INFO)
+
+	e := WithError(nil)
+	for i := 0; i < 5; i++ {
+		e = e.With(fmt.Sprint("k", i), i)
+	}
+	// two entries extended from the same one do not share fields.
+	a, b := e.With("a", 1), e.With("b", 2)
+	a.Info("a")
+	b.Info("b")
+
+	lines := strings.Split(buf.String(), "\n")
+	if !strings.HasSuffix(lines[0], ": a k0=0 k1=1 k2=2 k3=3 k4=4 a=1") ||
+		!strings.HasSuffix(lines[1], ": b k0=0 k1=1 k2=2 k3=3 k4=4 b=2") {
+		t.Errorf("got:\n%s", buf.String())
+	}
+}
+
+func TestErrorWDisabledNoAlloc(t *testing.T) {
+	captureGlobal(t, LEVEL_CRITICAL)
+	err := errors.New("boom")
+	allocs := testing.AllocsPerRun(100, func() {
+		ErrorW("hidden", err, "user", "alice", "op", "save")
+	})
+	if allocs != 0 {
+		t.Errorf("disabled entry allocated %v times", allocs)
+	}
+}
+
+func TestErrorW(t *testing.T) {
+	buf := captureGlobal(t, LEVEL_INFO)
+
+	err := fmt.Errorf("dial: %w", errors.New("connection refused"))
+	ErrorW("connect failed 100%", err, "host", "db1", "attempt", 3)
+	ErrorW("odd fields", nil, "host")
+
+	re := regexp.MustCompile(`^` +
+		headerRe + `\[ERROR\] errentry_test.go:\d+: connect failed 100% error="dial: connection refused" error_type=\*fmt.wrapError error_chain=\["connection refused"\] host=db1 attempt=3\n` +
+		headerRe + `\[ERROR\] errentry_test.go:\d+: odd fields host=MISSING\n$`)
+	if !re.MatchString(buf.String()) {
+		t.Errorf("got:\n%s", buf.String())
+	}
+
+	var lbuf bytes.Buffer
+	l := &Logger{out: &lbuf, level: LEVEL_ERROR, shortfile: true}
+	l.ErrorW("save failed", errors.New("disk full"), "user", 42)
+	if !strings.HasSuffix(lbuf.String(), `errentry_test.go:90: save failed error="disk full" error_type=*errors.errorString user=42`+"\n") {
+		t.Errorf("got %q", lbuf.String())
+	}
+}
+
+func TestWithErrorDisabledNoAlloc(t *testing.T) {
+	captureGlobal(t, LEVEL_INFO)
+	err := fmt.Errorf("save: %w", errors.New("boom"))
+	allocs := testing.AllocsPerRun(100, func() {
+		WithError(err).With("user", "alice").With("op", "save").Debug("hidden")
+	})
+	if allocs != 0 {
+		t.Errorf("disabled entry allocated %v times", allocs)
+	}
+}
+
+func TestWithErrorLogger(t *testing.T) {
+	buf := captureGlobal(t, LEVEL_INFO)
+
+	l := WithError(errors.New("timeout")).With("peer", "db1").Logger()
+	l.Warn("ping")
+	l.WithScope("try", 2).Info("again")
+
+	re := regexp.MustCompile(`^` +
+		headerRe + `\[WARNING\] errentry_test.go:\d+: ping error="timeout" error_type=\*errors.errorString peer=db1\n` +
+		headerRe + `\[INFO\] errentry_test.go:\d+: again error="timeout" error_type=\*errors.errorString peer=db1 try=2\n$`)
+	if !re.MatchString(buf.String()) {
+		t.Errorf("got:\n%s", buf.String())
+	}
+}
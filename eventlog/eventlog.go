@@ -0,0 +1,51 @@
+// Package eventlog sends golog lines to the Windows Event Log, where
+// administrators see them in Event Viewer or with wevtutil.
+//
+//	w, err := eventlog.NewWindowsEventLogWriter("myservice")
+//	if err != nil {
+//		...
+//	}
+//	golog.SetOutput(w)
+//
+// The event source must be registered once, usually by the installer
+// running as administrator (eventlog.InstallAsEventCreate in
+// golang.org/x/sys/windows/svc/eventlog). On other platforms
+// NewWindowsEventLogWriter returns an error.
+package eventlog
+
+import (
+	"bytes"
+)
+
+// eventID is the id of every event we report.
+const eventID = 1
+
+type eventType int
+
+const (
+	eventInfo eventType = iota
+	eventWarning
+	eventError
+)
+
+// typeOf maps the level in a golog header to an event type:
+// ERROR and above are errors, WARNING is a warning and everything else
+// is informational.
+func typeOf(line []byte) eventType {
+	i := bytes.Index(line, []byte(" ["))
+	if i < 0 {
+		return eventInfo
+	}
+	rest := line[i+2:]
+	j := bytes.IndexByte(rest, ']')
+	if j < 0 {
+		return eventInfo
+	}
+	switch string(rest[:j]) {
+	case "EMERGENCY", "ALERT", "CRITICAL", "ERROR":
+		return eventError
+	case "WARNING":
+		return eventWarning
+	}
+	return eventInfo
+}
@@ -0,0 +1,13 @@
+//go:build !windows
+
+package eventlog
+
+import (
+	"errors"
+	"io"
+)
+
+// NewWindowsEventLogWriter is only available on Windows.
+func NewWindowsEventLogWriter(source string) (io.WriteCloser, error) {
+	return nil, errors.New("golog/eventlog: the event log is only available on windows")
+}
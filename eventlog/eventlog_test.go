@@ -0,0 +1,20 @@
+package eventlog
+
+import (
+	"testing"
+)
+
+func TestTypeOf(t *testing.T) {
+	for line, want := range map[string]eventType{
+		"2015-05-14 09:56:00.023132 [CRITICAL] x.go:12: down": eventError,
+		"2015-05-14 09:56:00.023132 [ERROR] x.go:12: boom":    eventError,
+		"2015-05-14 09:56:00.023132 [WARNING] x.go:12: slow":  eventWarning,
+		"2015-05-14 09:56:00.023132 [NOTICE] x.go:12: hi":     eventInfo,
+		"2015-05-14 09:56:00.023132 [INFO] x.go:12: [ERROR]":  eventInfo,
+		"no header": eventInfo,
+	} {
+		if got := typeOf([]byte(line)); got != want {
+			t.Errorf("typeOf(%q) = %v, want %v", line, got, want)
+		}
+	}
+}
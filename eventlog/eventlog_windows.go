@@ -0,0 +1,46 @@
+//go:build windows
+
+package eventlog
+
+import (
+	"bytes"
+	"io"
+
+	"golang.org/x/sys/windows/svc/eventlog"
+)
+
+type writer struct {
+	log *eventlog.Log
+}
+
+// NewWindowsEventLogWriter returns a writer reporting every log line as
+// an event from source.
+func NewWindowsEventLogWriter(source string) (io.WriteCloser, error) {
+	log, err := eventlog.Open(source)
+	if err != nil {
+		return nil, err
+	}
+	return &writer{log: log}, nil
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	msg := string(bytes.TrimRight(p, "\r\n"))
+
+	var err error
+	switch typeOf(p) {
+	case eventError:
+		err = w.log.Error(eventID, msg)
+	case eventWarning:
+		err = w.log.Warning(eventID, msg)
+	default:
+		err = w.log.Info(eventID, msg)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w *writer) Close() error {
+	return w.log.Close()
+}
@@ -0,0 +1,79 @@
+package golog
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DrainAndClose gets the global logger ready for the process to exit,
+// see Logger.DrainAndClose.
+func DrainAndClose(timeout time.Duration) error {
+	return std().DrainAndClose(timeout)
+}
+
+// DrainAndClose gets l ready for the process to exit: the rotation is
+// stopped, records queued by DropWithTimeout are written, waiting at
+// most timeout, the log file is synced and closed, flushing a
+// compressed stream, and so are the sinks. It returns an error if
+// records were left queued or closing failed.
+//
+// Only the first call does the work, later and concurrent calls wait
+// for it and return its result. Records logged afterwards go to stderr.
+func (l *Logger) DrainAndClose(timeout time.Duration) error {
+	l = l.root()
+	l.drainOnce.Do(func() { l.drainErr = l.drainAndClose(timeout) })
+	return l.drainErr
+}
+
+func (l *Logger) drainAndClose(timeout time.Duration) error {
+	l.mu.RLock()
+	r := l.rotator
+	l.mu.RUnlock()
+	if r != nil {
+		r.stop()
+	}
+
+	var err error
+	if !l.drain(timeout) {
+		err = fmt.Errorf("golog: %d records not written within %v",
+			atomic.LoadInt64(&l.pending), timeout)
+	}
+
+	l.mu.RLock()
+	file := l.file
+	l.mu.RUnlock()
+	if file != nil {
+		if serr := file.Sync(); err == nil {
+			err = serr
+		}
+	}
+	if cerr := l.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+var exitHandlerOnce sync.Once
+
+// RegisterExitHandler makes SIGINT and SIGTERM, which otherwise kill
+// the process with records still queued or buffered, call
+// DrainAndClose(time.Second) on the global logger before the process
+// exits on the signal as it would have. Programs handling these signals
+// for their own shutdown should call DrainAndClose on that path instead.
+// Emergency always drains.
+func RegisterExitHandler() {
+	exitHandlerOnce.Do(func() {
+		c := make(chan os.Signal, 1)
+		signal.Notify(c, exitSignals...)
+		go func() {
+			sig := <-c
+			DrainAndClose(time.Second)
+			signal.Reset(sig)
+			reraise(sig)
+		}()
+	})
+}
@@ -0,0 +1,59 @@
+package golog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDrainAndClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	l := &Logger{level: LEVEL_INFO}
+	if err := l.openFile(path); err != nil {
+		t.Fatal(err)
+	}
+	l.SetWritePolicy(DropWithTimeout(time.Minute))
+
+	const n = 5000
+	for i := 0; i < n; i++ {
+		l.Info("line %d", i)
+	}
+
+	// concurrent callers all wait for the one drain.
+	var wg sync.WaitGroup
+	errs := make([]error, 4)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = l.DrainAndClose(5 * time.Second)
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			t.Fatalf("DrainAndClose() = %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != n {
+		t.Fatalf("%d lines written, want %d", len(lines), n)
+	}
+	for i, line := range lines {
+		if !strings.HasSuffix(line, fmt.Sprintf(": line %d", i)) {
+			t.Fatalf("line %d = %q", i, line)
+		}
+	}
+	if err := l.DrainAndClose(time.Second); err != nil {
+		t.Errorf("second DrainAndClose() = %v", err)
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !windows
+
+package golog
+
+import (
+	"os"
+	"syscall"
+)
+
+var exitSignals = []os.Signal{syscall.SIGINT, syscall.SIGTERM}
+
+// reraise delivers sig again, now with its default action.
+func reraise(sig os.Signal) {
+	syscall.Kill(os.Getpid(), sig.(syscall.Signal))
+}
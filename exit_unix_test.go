@@ -0,0 +1,48 @@
+//go:build !windows
+
+package golog
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRegisterExitHandler(t *testing.T) {
+	if path := os.Getenv("GOLOG_EXIT_LOG"); path != "" {
+		SetFile(path)
+		SetLevel(LEVEL_INFO)
+		SetWritePolicy(DropWithTimeout(time.Minute))
+		RegisterExitHandler()
+		for i := 0; i < 1000; i++ {
+			Info("line %d", i)
+		}
+		syscall.Kill(os.Getpid(), syscall.SIGTERM)
+		time.Sleep(10 * time.Second)
+		return
+	}
+
+	path := filepath.Join(t.TempDir(), "app.log")
+	cmd := exec.Command(os.Args[0], "-test.run=^TestRegisterExitHandler$")
+	cmd.Env = append(os.Environ(), "GOLOG_EXIT_LOG="+path)
+	out, err := cmd.CombinedOutput()
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) ||
+		exitErr.Sys().(syscall.WaitStatus).Signal() != syscall.SIGTERM {
+		t.Fatalf("child not killed by SIGTERM: %v\n%s", err, out)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(string(data), fmt.Sprintf(": line %d\n", 999)) {
+		t.Errorf("log not drained, ends with %q", data[max(0, len(data)-80):])
+	}
+}
@@ -0,0 +1,11 @@
+package golog
+
+import "os"
+
+var exitSignals = []os.Signal{os.Interrupt}
+
+// reraise exits as the default handling of sig would have; a console
+// control event cannot be raised again.
+func reraise(sig os.Signal) {
+	exit(1)
+}
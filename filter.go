@@ -0,0 +1,112 @@
+package golog
+
+import (
+	"regexp"
+	"sync/atomic"
+)
+
+type filter struct {
+	id      uint64
+	f       func(level int32, file string, line int, msg string) bool
+	counted bool // drops count as Filtered in Stats
+}
+
+var filterID uint64
+
+func AddFilter(f func(level int32, file string, msg string) bool) (remove func()) {
+	return std().AddFilter(f)
+}
+
+// AddFilter registers f to decide, for every record that passes the
+// level check, whether it is written: f gets the level, the caller's
+// full file path and the formatted message, and returning false drops
+// the record (counted as Filtered in Stats). Filters run outside the
+// logger's lock, concurrently, and must be safe for that.
+//
+// The returned function removes the filter.
+func (l *Logger) AddFilter(f func(level int32, file string, msg string) bool) (remove func()) {
+	return l.addFilter(func(level int32, file string, line int, msg string) bool {
+		return f(level, file, msg)
+	}, true)
+}
+
+func SetFilter(fn func(level int32, file string, line int, msg string) bool) {
+	std().SetFilter(fn)
+}
+
+// SetFilter adds fn to the filters of l, like AddFilter, but fn also
+// gets the caller's line and the records it drops are not counted
+// anywhere, as if they were never logged. A record is written only if
+// every filter lets it through. SetFilter(nil) removes all filters set
+// by SetFilter.
+func (l *Logger) SetFilter(fn func(level int32, file string, line int, msg string) bool) {
+	if fn != nil {
+		l.addFilter(fn, false)
+		return
+	}
+	l.removeFilters(func(flt filter) bool { return !flt.counted })
+}
+
+func (l *Logger) addFilter(f func(level int32, file string, line int, msg string) bool,
+	counted bool) (remove func()) {
+
+	id := atomic.AddUint64(&filterID, 1)
+
+	l.filterMu.Lock()
+	var filters []filter
+	if cur := l.filters.Load(); cur != nil {
+		filters = append(filters, *cur...)
+	}
+	filters = append(filters, filter{id, f, counted})
+	l.filters.Store(&filters)
+	l.filterMu.Unlock()
+
+	return func() {
+		l.removeFilters(func(flt filter) bool { return flt.id == id })
+	}
+}
+
+// removeFilters removes the filters drop returns true for.
+func (l *Logger) removeFilters(drop func(filter) bool) {
+	l.filterMu.Lock()
+	defer l.filterMu.Unlock()
+
+	cur := l.filters.Load()
+	if cur == nil {
+		return
+	}
+	var rest []filter
+	for _, flt := range *cur {
+		if !drop(flt) {
+			rest = append(rest, flt)
+		}
+	}
+	if len(rest) == 0 {
+		l.filters.Store(nil)
+	} else {
+		l.filters.Store(&rest)
+	}
+}
+
+func SuppressRegexp(level int32, re *regexp.Regexp) (remove func()) {
+	return std().SuppressRegexp(level, re)
+}
+
+// SuppressRegexp drops records at level, or less severe, whose message
+// matches re. More severe records always get through.
+func (l *Logger) SuppressRegexp(level int32, re *regexp.Regexp) (remove func()) {
+	return l.AddFilter(func(lvl int32, file string, msg string) bool {
+		return lvl < level || !re.MatchString(msg)
+	})
+}
+
+// runFilters tells whether the record passes all filters and, if not,
+// whether the drop is counted.
+func runFilters(filters []filter, level int32, file string, line int, msg string) (ok, counted bool) {
+	for _, flt := range filters {
+		if !flt.f(level, file, line, msg) {
+			return false, flt.counted
+		}
+	}
+	return true, false
+}
@@ -0,0 +1,114 @@
+package golog
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestFilters(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := &Logger{out: buf, level: LEVEL_INFO}
+
+	removeRe := l.SuppressRegexp(LEVEL_WARNING, regexp.MustCompile(`^benign \d+$`))
+	var files []string
+	removeFile := l.AddFilter(func(level int32, file string, msg string) bool {
+		files = append(files, file)
+		return msg != "drop me"
+	})
+
+	l.output(LEVEL_WARNING, "benign %d", 42)
+	l.output(LEVEL_INFO, "benign %d", 43)
+	l.output(LEVEL_ERROR, "benign %d", 44)
+	l.output(LEVEL_INFO, "drop me")
+	l.output(LEVEL_INFO, "keep me")
+
+	out := buf.String()
+	if strings.Count(out, "\n") != 2 || !strings.Contains(out, "[ERROR] ") ||
+		!strings.Contains(out, ": keep me\n") {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+	if got := l.Stats().Filtered; got != 3 {
+		t.Fatalf("Filtered = %d, want 3", got)
+	}
+	if len(files) == 0 || !strings.HasSuffix(files[0], ".go") {
+		t.Fatalf("filter got files %q", files)
+	}
+
+	removeRe()
+	removeFile()
+	removeFile()
+	l.output(LEVEL_WARNING, "benign %d", 45)
+	l.output(LEVEL_INFO, "drop me")
+	if got := strings.Count(buf.String(), "\n"); got != 4 {
+		t.Fatalf("filters still active after removal:\n%s", buf)
+	}
+}
+
+func TestFiltersConcurrent(t *testing.T) {
+	l := &Logger{out: &bytes.Buffer{}, level: LEVEL_INFO}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				remove := l.AddFilter(func(int32, string, string) bool { return true })
+				l.output(LEVEL_INFO, "x")
+				remove()
+			}
+		}()
+	}
+	wg.Wait()
+	if l.filters.Load() != nil {
+		t.Fatal("filters left behind")
+	}
+}
+
+func TestSetFilter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := &Logger{out: buf, level: LEVEL_INFO}
+	// one more frame, so the caller is this file, not testing.go.
+	output := func(level int32, msg string) { l.output(level, "%s", msg) }
+
+	var lines []int
+	l.SetFilter(func(level int32, file string, line int, msg string) bool {
+		lines = append(lines, line)
+		return !strings.Contains(msg, "/healthz")
+	})
+	l.SetFilter(func(level int32, file string, line int, msg string) bool {
+		return level <= LEVEL_NOTICE || strings.HasSuffix(file, "filter_test.go")
+	})
+	remove := l.AddFilter(func(level int32, file string, msg string) bool {
+		return msg != "counted"
+	})
+
+	output(LEVEL_INFO, "GET /healthz")
+	output(LEVEL_INFO, "GET /users")
+	l.output(LEVEL_INFO, "from testing.go")
+	output(LEVEL_INFO, "counted")
+
+	if out := buf.String(); strings.Count(out, "\n") != 1 || !strings.Contains(out, ": GET /users\n") {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+	if got := l.Stats().Filtered; got != 1 {
+		t.Fatalf("Filtered = %d, want 1", got)
+	}
+	if len(lines) == 0 || lines[0] == 0 {
+		t.Fatalf("filter got lines %v", lines)
+	}
+
+	// nil removes the SetFilter filters only.
+	l.SetFilter(nil)
+	output(LEVEL_INFO, "GET /healthz")
+	output(LEVEL_INFO, "counted")
+	if out := buf.String(); strings.Count(out, "\n") != 2 || l.Stats().Filtered != 2 {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+	remove()
+	if l.filters.Load() != nil {
+		t.Fatal("filters left behind")
+	}
+}
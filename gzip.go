@@ -0,0 +1,95 @@
+package golog
+
+import (
+	"compress/gzip"
+	"os"
+	"sync"
+	"time"
+)
+
+// how often a compressed log is flushed, bounding what a crash loses.
+var gzipFlushInterval = time.Second
+
+// gzipFile is a log file written as a gzip stream.
+type gzipFile struct {
+	mu   sync.Mutex
+	f    *os.File
+	gz   *gzip.Writer
+	stop chan struct{}
+}
+
+func newGzipFile(path string, perm os.FileMode) (*gzipFile, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, perm)
+	if err != nil {
+		return nil, err
+	}
+	g := &gzipFile{
+		f:    f,
+		gz:   gzip.NewWriter(f),
+		stop: make(chan struct{}),
+	}
+	go g.flushLoop(time.NewTicker(gzipFlushInterval))
+	return g, nil
+}
+
+func (g *gzipFile) flushLoop(ticker *time.Ticker) {
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			g.mu.Lock()
+			g.gz.Flush()
+			g.mu.Unlock()
+		case <-g.stop:
+			return
+		}
+	}
+}
+
+func (g *gzipFile) Write(p []byte) (int, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	select {
+	case <-g.stop:
+		return 0, os.ErrClosed
+	default:
+	}
+	return g.gz.Write(p)
+}
+
+// Close ends the gzip stream and closes the file.
+func (g *gzipFile) Close() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	select {
+	case <-g.stop:
+		return os.ErrClosed
+	default:
+	}
+	close(g.stop)
+	err := g.gz.Close()
+	if cerr := g.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// SetFileCompressed logs to a gzip compressed file at path.
+//
+// The stream is flushed every second so the file can be read (e.g. with
+// zcat) while it is written, and finished by Close, ReOpen and rotation,
+// so every rotated file is a complete gzip file on its own. A process
+// that dies without Close leaves the file without the gzip trailer:
+// everything up to the last flush can still be read, but tools will
+// report an unexpected end of file. Appending to an existing file adds
+// a new gzip member, which gzip readers handle transparently.
+func SetFileCompressed(path string) {
+	s, err := openFileSink(path, true, defaultPerm)
+	if err != nil {
+		Error("error on SetFileCompressed: err: %s", err)
+		return
+	}
+
+	std().setFile(s)
+}
@@ -0,0 +1,62 @@
+package golog
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func gunzip(t *testing.T, path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	r, err := gzip.NewReader(f)
+	if err != nil {
+		return "", err
+	}
+	data, err := io.ReadAll(r)
+	return string(data), err
+}
+
+func TestSetFileCompressed(t *testing.T) {
+	orig := GetGlobalLogger()
+	defer func() { SetGlobalLogger(orig) }()
+	SetGlobalLogger(&Logger{out: io.Discard, level: LEVEL_INFO})
+
+	oldInterval := gzipFlushInterval
+	defer func() { gzipFlushInterval = oldInterval }()
+	gzipFlushInterval = 10 * time.Millisecond
+
+	path := filepath.Join(t.TempDir(), "app.log.gz")
+	SetFileCompressed(path)
+	Info("first")
+
+	// readable before Close, up to the last flush.
+	time.Sleep(50 * time.Millisecond)
+	data, err := gunzip(t, path)
+	if err != io.ErrUnexpectedEOF || !strings.Contains(data, ": first\n") {
+		t.Fatalf("live file: %q, %v", data, err)
+	}
+
+	// rotation finishes the stream and starts a new one.
+	if err := std().rotate(path + ".1"); err != nil {
+		t.Fatal(err)
+	}
+	Info("second")
+	if err := Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]string{path + ".1": ": first\n", path: ": second\n"} {
+		data, err := gunzip(t, name)
+		if err != nil || !strings.HasSuffix(data, want) || strings.Count(data, "\n") != 1 {
+			t.Errorf("%s: %q, %v", name, data, err)
+		}
+	}
+}
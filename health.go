@@ -0,0 +1,172 @@
+package golog
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// HealthCheck fails when the queue of DropWithTimeout has been full for
+// this long.
+var queueFullThreshold = 5 * time.Second
+
+func OnError(fn func(op string, err error)) {
+	std().OnError(fn)
+}
+
+// OnError makes l call fn when rotating ("rotate"), reopening the log
+// file ("reopen") or deleting expired logs ("cleanup") fails, a record
+// is dropped by the write timeout ("write") or a sink added by AddSink
+// fails to take a queued record ("sink"), errors that can
+// otherwise only be logged, maybe to the broken output. fn is called
+// without any lock of l held, so it may log, though a record logged on
+// a "write" error is likely dropped too. nil removes it.
+func (l *Logger) OnError(fn func(op string, err error)) {
+	if fn == nil {
+		l.onError.Store(nil)
+		return
+	}
+	l.onError.Store(&fn)
+}
+
+// recordError keeps an error of op, see OnError, for LastError. A
+// rotation error is reported by HealthCheck until a rotation succeeds:
+// call recordError("rotate", nil) then. l.mu may be held.
+func (l *Logger) recordError(op string, err error) {
+	h := &l.health
+	now := time.Now()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err != nil {
+		h.lastErr, h.lastErrAt = err, now
+	}
+	if op == "rotate" {
+		h.rotateErr, h.rotateErrAt = err, now
+	}
+}
+
+// reportError passes a failure of op to the OnError callback, l.mu must
+// not be held.
+func (l *Logger) reportError(op string, err error) {
+	if fn := l.onError.Load(); fn != nil && err != nil {
+		(*fn)(op, err)
+	}
+}
+
+// noteError records err and reports it, l.mu must not be held.
+func (l *Logger) noteError(op string, err error) {
+	l.recordError(op, err)
+	l.reportError(op, err)
+}
+
+func LastError() (time.Time, error) {
+	return std().LastError()
+}
+
+// LastError returns the last error l ran into writing, rotating or
+// deleting expired logs, and when; a zero time and nil if there was
+// none.
+func (l *Logger) LastError() (time.Time, error) {
+	h := &l.health
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.lastErrAt, h.lastErr
+}
+
+func HealthCheck() error {
+	return std().HealthCheck()
+}
+
+// HealthCheck returns an error, e.g. for a readiness probe, if l can not
+// log right now: the output is degraded after write errors or its file
+// is unusable, the DropWithTimeout queue has been full for a while, or
+// the last rotation failed.
+func (l *Logger) HealthCheck() error {
+	l.mu.RLock()
+	out := l.out
+	l.mu.RUnlock()
+
+	var f *os.File
+	switch w := out.(type) {
+	case *os.File:
+		f = w
+	case *gzipFile:
+		f = w.f
+	case fileWriter:
+		f = w.s.file()
+	}
+	if f != nil {
+		if _, err := f.Stat(); err != nil {
+			return fmt.Errorf("golog: output unusable: %w", err)
+		}
+	}
+
+	h := &l.health
+	h.mu.Lock()
+	degraded, since := h.degraded, h.since
+	rotateErr, rotateErrAt := h.rotateErr, h.rotateErrAt
+	h.mu.Unlock()
+	if degraded {
+		return fmt.Errorf("golog: output degraded since %v", since.Format(time.RFC3339))
+	}
+
+	if full := atomic.LoadInt64(&l.queueFullSince); full != 0 {
+		if d := time.Since(time.Unix(0, full)); d > queueFullThreshold {
+			return fmt.Errorf("golog: write queue full for %v", d.Round(time.Second))
+		}
+	}
+
+	if rotateErr != nil {
+		return fmt.Errorf("golog: rotation failed at %v: %w", rotateErrAt.Format(time.RFC3339), rotateErr)
+	}
+	return nil
+}
+
+// healthReport is what Handler serves.
+type healthReport struct {
+	Healthy       bool       `json:"healthy"`
+	Error         string     `json:"error,omitempty"`
+	Degraded      bool       `json:"degraded"`
+	WriteErrors   uint64     `json:"write_errors"`
+	Dropped       uint64     `json:"dropped"`
+	LastError     string     `json:"last_error,omitempty"`
+	LastErrorTime *time.Time `json:"last_error_time,omitempty"`
+}
+
+// Handler serves the health of the global logger as JSON, with status
+// 503 when HealthCheck fails, e.g. mounted at /healthz.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		l := std()
+		st := l.Stats()
+		rep := healthReport{
+			Healthy:     true,
+			Degraded:    st.Degraded,
+			WriteErrors: st.WriteErrors,
+			Dropped:     st.Dropped,
+		}
+		if err := l.HealthCheck(); err != nil {
+			rep.Healthy = false
+			rep.Error = err.Error()
+		}
+		if at, err := l.LastError(); err != nil {
+			rep.LastError = err.Error()
+			rep.LastErrorTime = &at
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if !rep.Healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(rep)
+	})
+}
@@ -0,0 +1,139 @@
+package golog
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestHealthCheck(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "logs")
+	os.Mkdir(dir, 0777)
+	l := &Logger{level: LEVEL_INFO, maxLines: 2}
+	if err := l.openFile(filepath.Join(dir, "app.log")); err != nil {
+		t.Fatal(err)
+	}
+	l.output(LEVEL_INFO, "fine")
+	if err := l.HealthCheck(); err != nil {
+		t.Fatal(err)
+	}
+	if at, err := l.LastError(); !at.IsZero() || err != nil {
+		t.Fatalf("LastError() = %v, %v", at, err)
+	}
+
+	// the directory is gone, rotation fails.
+	os.RemoveAll(dir)
+	l.output(LEVEL_INFO, "full")
+	err := l.HealthCheck()
+	if err == nil || !strings.Contains(err.Error(), "rotation failed") {
+		t.Fatalf("HealthCheck() = %v", err)
+	}
+	if at, lerr := l.LastError(); at.IsZero() || !errors.Is(err, lerr) {
+		t.Errorf("LastError() = %v, %v", at, lerr)
+	}
+	// the output fell back to stderr, do not close that.
+	f, ferr := os.Create(filepath.Join(t.TempDir(), "closed.log"))
+	if ferr != nil {
+		t.Fatal(ferr)
+	}
+	f.Close()
+	l.out = f
+	if err := l.HealthCheck(); err == nil || !strings.Contains(err.Error(), "output unusable") {
+		t.Errorf("HealthCheck() with closed file = %v", err)
+	}
+
+	l = &Logger{out: &fullDisk{full: true}, level: LEVEL_INFO}
+	for i := 0; i < degradeAfter; i++ {
+		l.output(LEVEL_INFO, "line")
+	}
+	if err := l.HealthCheck(); err == nil || !strings.Contains(err.Error(), "degraded") {
+		t.Errorf("HealthCheck() when degraded = %v", err)
+	}
+	if _, err := l.LastError(); !errors.Is(err, syscall.ENOSPC) {
+		t.Errorf("LastError() = %v", err)
+	}
+
+	l = NewDiscardLogger()
+	atomic.StoreInt64(&l.queueFullSince, time.Now().Add(-time.Minute).UnixNano())
+	if err := l.HealthCheck(); err == nil || !strings.Contains(err.Error(), "queue full") {
+		t.Errorf("HealthCheck() with full queue = %v", err)
+	}
+}
+
+func TestHealthHandler(t *testing.T) {
+	orig := GetGlobalLogger()
+	defer func() { SetGlobalLogger(orig) }()
+	SetGlobalLogger(NewDiscardLogger())
+
+	get := func() (int, healthReport) {
+		rec := httptest.NewRecorder()
+		Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/healthz", nil))
+		var rep healthReport
+		if err := json.Unmarshal(rec.Body.Bytes(), &rep); err != nil {
+			t.Fatalf("%v: %s", err, rec.Body)
+		}
+		return rec.Code, rep
+	}
+	if code, rep := get(); code != http.StatusOK || !rep.Healthy {
+		t.Errorf("healthy logger: %d %+v", code, rep)
+	}
+
+	SetGlobalLogger(&Logger{out: &fullDisk{full: true}, level: LEVEL_INFO})
+	for i := 0; i < degradeAfter; i++ {
+		Info("line")
+	}
+	code, rep := get()
+	if code != http.StatusServiceUnavailable || rep.Healthy || !rep.Degraded ||
+		rep.WriteErrors != uint64(degradeAfter) || rep.LastErrorTime == nil ||
+		!strings.Contains(rep.LastError, "no space") {
+		t.Errorf("degraded logger: %d %+v", code, rep)
+	}
+}
+
+func TestOnError(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "logs")
+	os.Mkdir(dir, 0777)
+	l := &Logger{level: LEVEL_INFO, maxLines: 1}
+	if err := l.openFile(filepath.Join(dir, "app.log")); err != nil {
+		t.Fatal(err)
+	}
+	// the file, l.out is stderr by the time the test ends.
+	defer l.out.(io.Closer).Close()
+
+	var ops []string
+	l.OnError(func(op string, err error) {
+		l.MarshalConfig() // deadlocks if called with l.mu held
+		ops = append(ops, op)
+	})
+
+	// make the rename fail; root ignores permissions.
+	if os.Geteuid() == 0 {
+		os.RemoveAll(dir)
+	} else {
+		os.Chmod(dir, 0555)
+		defer os.Chmod(dir, 0777)
+	}
+	l.output(LEVEL_INFO, "rotate after this")
+	if len(ops) != 1 || ops[0] != "rotate" {
+		t.Errorf("OnError called for %v", ops)
+	}
+
+	l.OnError(nil)
+	l.output(LEVEL_INFO, "again")
+	if len(ops) != 1 {
+		t.Errorf("OnError called after removal: %v", ops)
+	}
+
+	if err := NewDiscardLogger().enableRotate(time.Hour); err != errNoFile {
+		t.Errorf("enableRotate without a file: %v", err)
+	}
+}
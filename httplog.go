@@ -0,0 +1,197 @@
+package golog
+
+import (
+	"bufio"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// statusWriter records the status and size of a response.
+type statusWriter struct {
+	http.ResponseWriter
+	status   int
+	size     int64
+	hijacked bool
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		f.Flush()
+	}
+}
+
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("golog: response writer does not support hijacking")
+	}
+	conn, rw, err := h.Hijack()
+	if err == nil {
+		w.hijacked = true
+	}
+	return conn, rw, err
+}
+
+// Unwrap lets http.ResponseController reach the original writer.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// HTTPMiddleware logs every request handled by next at level, in Apache
+// Combined Log Format followed by the time taken:
+//
+//	192.0.2.1 - alice [15/Oct/2026:09:30:00 +0200] "GET /x HTTP/1.1" 200 512 "-" "curl/8.0" 1.2ms
+//
+// A handler that writes without calling WriteHeader is logged with 200.
+// A hijacked connection, e.g. a WebSocket upgrade, is logged with what is
+// known, its status as "-" unless the handler set one. A panicking
+// handler is logged with 500 and the panic goes on.
+func HTTPMiddleware(next http.Handler, level int32) http.Handler {
+	return httpMiddleware(next, level, false)
+}
+
+// HTTPMiddlewareFields is HTTPMiddleware with the parts of the request
+// logged as key=value fields, for log shippers that parse fields rather
+// than Combined Log Format:
+//
+//	request remote=192.0.2.1 user=alice method=GET uri="/x" proto=HTTP/1.1 status=200 size=512 referer="-" user_agent="curl/8.0" duration=1.2ms
+func HTTPMiddlewareFields(next http.Handler, level int32) http.Handler {
+	return httpMiddleware(next, level, true)
+}
+
+func httpMiddleware(next http.Handler, level int32, fields bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !IsLevelEnabled(level) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w}
+		defer func() {
+			if p := recover(); p != nil {
+				sw.status = http.StatusInternalServerError
+				logRequest(level, r, sw, start, fields)
+				panic(p)
+			}
+			logRequest(level, r, sw, start, fields)
+		}()
+		next.ServeHTTP(sw, r)
+	})
+}
+
+func logRequest(level int32, r *http.Request, sw *statusWriter, start time.Time, fields bool) {
+	status := "-"
+	switch {
+	case sw.status != 0:
+		status = fmt.Sprint(sw.status)
+	case !sw.hijacked:
+		status = "200" // nothing written at all.
+	}
+	size := "-"
+	if sw.size > 0 {
+		size = fmt.Sprint(sw.size)
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	user := "-"
+	if name, _, ok := r.BasicAuth(); ok && name != "" {
+		user = name
+	} else if r.URL.User != nil && r.URL.User.Username() != "" {
+		user = r.URL.User.Username()
+	}
+
+	uri := r.RequestURI
+	if uri == "" {
+		uri = r.URL.RequestURI()
+	}
+
+	if fields {
+		std().withFields(
+			&field{"remote", host},
+			&field{"user", user},
+			&field{"method", r.Method},
+			&field{"uri", fmt.Sprintf("%q", uri)},
+			&field{"proto", r.Proto},
+			&field{"status", status},
+			&field{"size", size},
+			&field{"referer", fmt.Sprintf("%q", orDash(r.Referer()))},
+			&field{"user_agent", fmt.Sprintf("%q", orDash(r.UserAgent()))},
+			&field{"duration", time.Since(start)},
+		).outputDepth(1, level, "", "request")
+		return
+	}
+	std().outputDepth(1, level, "", "%s - %s [%s] \"%s %s %s\" %s %s %q %q %v",
+		host, user, start.Format("02/Jan/2006:15:04:05 -0700"),
+		r.Method, uri, r.Proto, status, size,
+		orDash(r.Referer()), orDash(r.UserAgent()), time.Since(start))
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// NewHTTPMiddleware puts the request's correlation ids, read from the
+// headers named by headerNames (X-Request-ID and X-Correlation-ID by
+// default), in its context with ContextWithLogPrefix, so the *CtxP
+// functions called with r.Context() tag their records with them. A
+// request without any of the headers gets a random UUID, which is also
+// set as the first header of the response.
+func NewHTTPMiddleware(next http.Handler, headerNames ...string) http.Handler {
+	if len(headerNames) == 0 {
+		headerNames = []string{"X-Request-ID", "X-Correlation-ID"}
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var ids []string
+		for _, name := range headerNames {
+			if id := r.Header.Get(name); id != "" {
+				ids = append(ids, id)
+			}
+		}
+		if ids == nil {
+			id := newUUID()
+			ids = append(ids, id)
+			w.Header().Set(headerNames[0], id)
+		}
+		ctx := ContextWithLogPrefix(r.Context(), strings.Join(ids, " "))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// newUUID returns a random (version 4) UUID.
+func newUUID() string {
+	var u [16]byte
+	rand.Read(u[:])
+	u[6] = u[6]&0x0f | 0x40
+	u[8] = u[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16])
+}
@@ -0,0 +1,150 @@
+package golog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestHTTPMiddleware(t *testing.T) {
+	buf := captureGlobal(t, LEVEL_INFO)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	})
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	mux.HandleFunc("/empty", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
+		conn, _, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		conn.Close()
+	})
+	mux.HandleFunc("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+	h := HTTPMiddleware(mux, LEVEL_INFO)
+
+	req := httptest.NewRequest("GET", "/ok?x=1", nil)
+	req.RemoteAddr = "192.0.2.1:5555"
+	req.SetBasicAuth("alice", "secret")
+	req.Header.Set("Referer", "http://example.com/")
+	req.Header.Set("User-Agent", "curl/8.0")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/missing", nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/empty", nil))
+
+	func() {
+		defer func() {
+			if p := recover(); p != "boom" {
+				t.Errorf("recovered %v, want the handler's panic", p)
+			}
+		}()
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/panic", nil))
+	}()
+
+	logged := make(chan bool)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(w, r)
+		logged <- true
+	}))
+	defer srv.Close()
+	if resp, err := http.Get(srv.URL + "/ws"); err == nil {
+		resp.Body.Close()
+	}
+	<-logged
+
+	clf := `\[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [-+]\d{4}\] `
+	re := regexp.MustCompile(`^` +
+		headerRe + `\[INFO\] httplog.go:\d+: 192.0.2.1 - alice ` + clf + `"GET /ok\?x=1 HTTP/1.1" 200 5 "http://example.com/" "curl/8.0" \S+\n` +
+		headerRe + `\[INFO\] httplog.go:\d+: 192.0.2.1 - - ` + clf + `"POST /missing HTTP/1.1" 404 19 "-" "-" \S+\n` +
+		headerRe + `\[INFO\] httplog.go:\d+: 192.0.2.1 - - ` + clf + `"GET /empty HTTP/1.1" 200 - "-" "-" \S+\n` +
+		headerRe + `\[INFO\] httplog.go:\d+: 192.0.2.1 - - ` + clf + `"GET /panic HTTP/1.1" 500 - "-" "-" \S+\n` +
+		headerRe + `\[INFO\] httplog.go:\d+: 127.0.0.1 - - ` + clf + `"GET /ws HTTP/1.1" - - "-" "Go-http-client/1.1" \S+\n$`)
+	if !re.MatchString(buf.String()) {
+		t.Errorf("got:\n%s", buf.String())
+	}
+}
+
+func TestHTTPMiddlewareFields(t *testing.T) {
+	buf := captureGlobal(t, LEVEL_INFO)
+	h := HTTPMiddlewareFields(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	}), LEVEL_INFO)
+
+	req := httptest.NewRequest("GET", "/ok?x=1", nil)
+	req.RemoteAddr = "192.0.2.1:5555"
+	req.SetBasicAuth("alice", "secret")
+	req.Header.Set("User-Agent", "curl/8.0 (x86_64)")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	re := regexp.MustCompile(`^` + headerRe + `\[INFO\] httplog.go:\d+: request remote=192.0.2.1 user=alice method=GET uri="/ok\?x=1" ` +
+		`proto=HTTP/1.1 status=200 size=5 referer="-" user_agent="curl/8.0 \(x86_64\)" duration=\S+\n$`)
+	if !re.MatchString(buf.String()) {
+		t.Errorf("got:\n%s", buf.String())
+	}
+}
+
+func TestHTTPMiddlewareDisabled(t *testing.T) {
+	buf := captureGlobal(t, LEVEL_NOTICE)
+	h := HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := w.(*httptest.ResponseRecorder); !ok {
+			t.Error("writer wrapped although the level is disabled")
+		}
+	}), LEVEL_INFO)
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if strings.TrimSpace(buf.String()) != "" {
+		t.Errorf("got %q", buf.String())
+	}
+}
+
+func TestNewHTTPMiddleware(t *testing.T) {
+	buf := captureGlobal(t, LEVEL_INFO)
+	h := NewHTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		InfoCtxP(r.Context(), "handled %s", r.URL.Path)
+	}))
+
+	req := httptest.NewRequest("GET", "/a", nil)
+	req.Header.Set("X-Request-ID", "req-1")
+	req.Header.Set("X-Correlation-ID", "corr-2")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Header().Get("X-Request-ID") != "" {
+		t.Errorf("response got id %q although the request had one", rec.Header().Get("X-Request-ID"))
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/b", nil))
+	id := rec.Header().Get("X-Request-ID")
+	if !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(id) {
+		t.Fatalf("generated id %q is not a UUID", id)
+	}
+
+	re := regexp.MustCompile(`^` +
+		headerRe + `\[INFO\] httplog_test.go:\d+: req-1 corr-2 handled /a\n` +
+		headerRe + `\[INFO\] httplog_test.go:\d+: ` + id + ` handled /b\n$`)
+	if !re.MatchString(buf.String()) {
+		t.Errorf("got:\n%s", buf.String())
+	}
+
+	// custom header names.
+	buf.Reset()
+	h = NewHTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		InfoCtxP(r.Context(), "custom")
+	}), "X-Trace")
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Request-ID", "ignored")
+	req.Header.Set("X-Trace", "trace-9")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	if !strings.HasSuffix(buf.String(), ": trace-9 custom\n") {
+		t.Errorf("got %q", buf.String())
+	}
+}
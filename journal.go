@@ -0,0 +1,79 @@
+package golog
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// journald's native protocol socket.
+var journalSocket = "/run/systemd/journal/socket"
+
+// SetJournal sends the log to the systemd journal, with the level as
+// PRIORITY and the caller as CODE_FILE/CODE_LINE, so `journalctl -p`
+// and friends work. It returns an error when there is no journal (not
+// a systemd host, most containers, other platforms); callers can then
+// fall back to SetFile.
+func SetJournal() error {
+	j, err := openJournal(journalSocket)
+	if err != nil {
+		return err
+	}
+
+	l := std()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.journal != nil {
+		l.journal.close()
+	}
+	l.journal = j
+	return nil
+}
+
+// journal priorities are the RFC5424 severities, VERBOSE is debug too.
+func journalPriority(level int32) int {
+	if level > LEVEL_DEBUG {
+		return LEVEL_DEBUG
+	}
+	return int(level)
+}
+
+var journalIdentifier = filepath.Base(os.Args[0])
+
+// appendJournalRecord encodes one record in the journal export format.
+func appendJournalRecord(buf []byte, level int32, file string, line int, msg []byte) []byte {
+	if n := len(msg); n > 0 && msg[n-1] == '\n' {
+		msg = msg[:n-1]
+	}
+	buf = appendJournalField(buf, "PRIORITY", strconv.AppendInt(nil, int64(journalPriority(level)), 10))
+	buf = appendJournalField(buf, "SYSLOG_IDENTIFIER", []byte(journalIdentifier))
+	buf = appendJournalField(buf, "CODE_FILE", []byte(file))
+	buf = appendJournalField(buf, "CODE_LINE", strconv.AppendInt(nil, int64(line), 10))
+	return appendJournalField(buf, "MESSAGE", msg)
+}
+
+// appendJournalField appends KEY=value, or the length-prefixed binary
+// form when value spans several lines.
+func appendJournalField(buf []byte, key string, value []byte) []byte {
+	buf = append(buf, key...)
+	if !containsNewline(value) {
+		buf = append(buf, '=')
+		buf = append(buf, value...)
+		return append(buf, '\n')
+	}
+	buf = append(buf, '\n')
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(len(value)))
+	buf = append(buf, value...)
+	return append(buf, '\n')
+}
+
+func containsNewline(b []byte) bool {
+	for _, c := range b {
+		if c == '\n' {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package golog
+
+import (
+	"errors"
+	"net"
+	"os"
+	"syscall"
+)
+
+// records that do not fit in a datagram and can not be passed as a
+// file descriptor are cut to this size.
+const maxJournalMessage = 48 * 1024
+
+type journal struct {
+	conn *net.UnixConn
+	buf  []byte // guarded by the logger's mu
+}
+
+func openJournal(path string) (*journal, error) {
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+	return &journal{conn: conn}, nil
+}
+
+func (j *journal) send(level int32, file string, line int, msg []byte) error {
+	j.buf = appendJournalRecord(j.buf[:0], level, file, line, msg)
+	_, err := j.conn.Write(j.buf)
+	if !errors.Is(err, syscall.EMSGSIZE) && !errors.Is(err, syscall.ENOBUFS) {
+		return err
+	}
+
+	// too big for a datagram: pass it in an unlinked file on tmpfs,
+	// the way sd_journal_send does when memfd is not at hand.
+	if err := j.sendFile(j.buf); err == nil {
+		return nil
+	}
+	if len(msg) > maxJournalMessage {
+		msg = msg[:maxJournalMessage]
+	}
+	j.buf = appendJournalRecord(j.buf[:0], level, file, line, msg)
+	_, err = j.conn.Write(j.buf)
+	return err
+}
+
+func (j *journal) sendFile(data []byte) error {
+	f, err := os.CreateTemp("/dev/shm", "golog-journal-")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		return err
+	}
+	// WriteMsgUnix refuses connected datagram sockets, use sendmsg.
+	rc, err := j.conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+	oob := syscall.UnixRights(int(f.Fd()))
+	werr := rc.Write(func(fd uintptr) bool {
+		err = syscall.Sendmsg(int(fd), nil, oob, nil, 0)
+		return err != syscall.EAGAIN
+	})
+	if werr != nil {
+		return werr
+	}
+	return err
+}
+
+func (j *journal) close() error {
+	return j.conn.Close()
+}
@@ -0,0 +1,110 @@
+package golog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func parseJournalRecord(t *testing.T, data []byte) map[string]string {
+	fields := map[string]string{}
+	for len(data) > 0 {
+		i := bytes.IndexAny(data, "=\n")
+		if i < 0 {
+			t.Fatalf("bad record %q", data)
+		}
+		key := string(data[:i])
+		if data[i] == '=' {
+			j := bytes.IndexByte(data, '\n')
+			fields[key] = string(data[i+1 : j])
+			data = data[j+1:]
+			continue
+		}
+		n := binary.LittleEndian.Uint64(data[i+1:])
+		fields[key] = string(data[i+9 : i+9+int(n)])
+		data = data[i+9+int(n)+1:]
+	}
+	return fields
+}
+
+// readJournal receives one record, inline or passed as a file.
+func readJournal(t *testing.T, conn *net.UnixConn) map[string]string {
+	buf := make([]byte, 1<<20)
+	oob := make([]byte, 64)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, oobn, _, _, err := conn.ReadMsgUnix(buf, oob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if oobn == 0 {
+		return parseJournalRecord(t, buf[:n])
+	}
+
+	msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	if err != nil {
+		t.Fatal(err)
+	}
+	fds, err := syscall.ParseUnixRights(&msgs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := os.NewFile(uintptr(fds[0]), "journal-record")
+	defer f.Close()
+	f.Seek(0, io.SeekStart)
+	data, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parseJournalRecord(t, data)
+}
+
+func TestSetJournal(t *testing.T) {
+	captureGlobal(t, LEVEL_INFO)
+	oldSocket := journalSocket
+	defer func() { journalSocket = oldSocket }()
+
+	journalSocket = filepath.Join(t.TempDir(), "missing")
+	if err := SetJournal(); err == nil {
+		t.Fatal("SetJournal succeeded without a journal")
+	}
+
+	journalSocket = filepath.Join(t.TempDir(), "socket")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: journalSocket, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if err := SetJournal(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { std().journal.close() }()
+
+	Warn("disk %s", "slow")
+	fields := readJournal(t, conn)
+	if fields["PRIORITY"] != "4" || fields["MESSAGE"] != "disk slow" ||
+		fields["CODE_FILE"] == "" || fields["CODE_LINE"] == "0" ||
+		fields["SYSLOG_IDENTIFIER"] != journalIdentifier {
+		t.Fatalf("unexpected fields %q", fields)
+	}
+
+	Error("two\nlines")
+	if fields := readJournal(t, conn); fields["MESSAGE"] != "two\nlines" || fields["PRIORITY"] != "3" {
+		t.Fatalf("unexpected fields %q", fields)
+	}
+
+	big := strings.Repeat("x", 512*1024)
+	Info("%s", big)
+	// passed as a file, or cut when /dev/shm is not available.
+	fields = readJournal(t, conn)
+	if len(fields["MESSAGE"]) != len(big) &&
+		len(fields["MESSAGE"]) != maxJournalMessage {
+		t.Fatalf("big message came through with %d bytes", len(fields["MESSAGE"]))
+	}
+}
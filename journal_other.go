@@ -0,0 +1,21 @@
+//go:build !linux
+
+package golog
+
+import (
+	"errors"
+)
+
+type journal struct{}
+
+func openJournal(path string) (*journal, error) {
+	return nil, errors.New("golog: the systemd journal is only available on linux")
+}
+
+func (j *journal) send(level int32, file string, line int, msg []byte) error {
+	return nil
+}
+
+func (j *journal) close() error {
+	return nil
+}
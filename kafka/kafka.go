@@ -0,0 +1,167 @@
+// Package kafka streams golog lines to a Kafka topic.
+//
+// It lives in its own package so that golog itself does not depend on
+// a Kafka client:
+//
+//	w, err := kafka.NewKafkaWriter([]string{"broker:9092"}, "app-logs")
+//	if err != nil {
+//		...
+//	}
+//	defer w.Close()
+//	golog.SetOutput(w)
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+type config struct {
+	batchSize    int
+	batchTimeout time.Duration
+	writeTimeout time.Duration
+	maxAttempts  int
+	async        bool
+	onError      func(err error)
+}
+
+// A KafkaOption changes the defaults of NewKafkaWriter.
+type KafkaOption func(*config)
+
+// WithBatch buffers up to size lines, or for at most timeout, before
+// sending them in one request. The default is 100 lines / 100ms. It only
+// applies with WithAsync: a synchronous Write is made with the logger
+// locked, so it sends its line right away rather than wait for a batch.
+func WithBatch(size int, timeout time.Duration) KafkaOption {
+	return func(c *config) {
+		c.batchSize = size
+		c.batchTimeout = timeout
+	}
+}
+
+// WithWriteTimeout bounds each attempt to deliver a batch. The default
+// is 5s.
+func WithWriteTimeout(d time.Duration) KafkaOption {
+	return func(c *config) {
+		c.writeTimeout = d
+	}
+}
+
+// WithRetries sets how many times a batch is attempted before the
+// lines are given up. The default is 3.
+func WithRetries(n int) KafkaOption {
+	return func(c *config) {
+		c.maxAttempts = n
+	}
+}
+
+// WithAsync makes Write return as soon as the line is buffered, delivery
+// errors then go to the function given to WithErrorHandler, or are lost.
+func WithAsync() KafkaOption {
+	return func(c *config) {
+		c.async = true
+	}
+}
+
+// WithErrorHandler makes fn get the errors of asynchronous deliveries,
+// see WithAsync, e.g. to count them. fn is called from the client's
+// goroutine and must not log through the writer.
+func WithErrorHandler(fn func(err error)) KafkaOption {
+	return func(c *config) {
+		c.onError = fn
+	}
+}
+
+type writer struct {
+	w       *kafkago.Writer
+	timeout time.Duration
+}
+
+// NewKafkaWriter returns a writer producing one message per log line to
+// topic. The message value is the raw line and the "level" header holds
+// the level name, e.g. "ERROR".
+func NewKafkaWriter(brokers []string, topic string, opts ...KafkaOption) (io.WriteCloser, error) {
+	if len(brokers) == 0 {
+		return nil, errors.New("golog/kafka: no brokers")
+	}
+	if topic == "" {
+		return nil, errors.New("golog/kafka: no topic")
+	}
+
+	c := config{
+		batchSize:    100,
+		batchTimeout: 100 * time.Millisecond,
+		writeTimeout: 5 * time.Second,
+		maxAttempts:  3,
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	if !c.async {
+		c.batchSize, c.batchTimeout = 1, 0
+	}
+
+	w := &kafkago.Writer{
+		Addr:         kafkago.TCP(brokers...),
+		Topic:        topic,
+		Balancer:     &kafkago.LeastBytes{},
+		BatchSize:    c.batchSize,
+		BatchTimeout: c.batchTimeout,
+		WriteTimeout: c.writeTimeout,
+		MaxAttempts:  c.maxAttempts,
+		Async:        c.async,
+	}
+	if c.async && c.onError != nil {
+		onError := c.onError
+		w.Completion = func(messages []kafkago.Message, err error) {
+			if err != nil {
+				onError(err)
+			}
+		}
+	}
+	// a sync write waits for every attempt.
+	timeout := time.Duration(c.maxAttempts) * c.writeTimeout
+	return &writer{w: w, timeout: timeout}, nil
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	// golog reuses its buffer, the client may keep the message.
+	line := bytes.TrimSuffix(p, []byte{'\n'})
+	msg := kafkago.Message{
+		Value: append([]byte(nil), line...),
+		Headers: []kafkago.Header{
+			{Key: "level", Value: levelOf(line)},
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), w.timeout)
+	defer cancel()
+	if err := w.w.WriteMessages(ctx, msg); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w *writer) Close() error {
+	return w.w.Close()
+}
+
+// levelOf returns the level name from a golog header,
+// "2015-05-14 09:56:00.023132 [ERROR] x.go:12: ..." gives "ERROR".
+func levelOf(line []byte) []byte {
+	i := bytes.Index(line, []byte(" ["))
+	if i < 0 {
+		return nil
+	}
+	rest := line[i+2:]
+	j := bytes.IndexByte(rest, ']')
+	if j < 0 {
+		return nil
+	}
+	return append([]byte(nil), rest[:j]...)
+}
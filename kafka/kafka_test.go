@@ -0,0 +1,48 @@
+package kafka
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLevelOf(t *testing.T) {
+	for line, want := range map[string]string{
+		"2015-05-14 09:56:00.023132 [ERROR] x.go:12: boom": "ERROR",
+		"2015-05-14 09:56:00.023132 [VERB] x.go:12: a [b]": "VERB",
+		"no header": "",
+	} {
+		if got := string(levelOf([]byte(line))); got != want {
+			t.Errorf("levelOf(%q) = %q, want %q", line, got, want)
+		}
+	}
+}
+
+func TestNewKafkaWriterArgs(t *testing.T) {
+	if _, err := NewKafkaWriter(nil, "logs"); err == nil {
+		t.Error("accepted no brokers")
+	}
+	if _, err := NewKafkaWriter([]string{"localhost:9092"}, ""); err == nil {
+		t.Error("accepted empty topic")
+	}
+}
+
+func TestSyncWriterDoesNotBatch(t *testing.T) {
+	wc, err := NewKafkaWriter([]string{"localhost:9092"}, "logs", WithBatch(100, time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := wc.(*writer)
+	if w.w.BatchSize != 1 || w.w.BatchTimeout != 0 {
+		t.Errorf("sync writer batches %d lines / %v", w.w.BatchSize, w.w.BatchTimeout)
+	}
+
+	wc, err = NewKafkaWriter([]string{"localhost:9092"}, "logs", WithAsync(),
+		WithErrorHandler(func(error) {}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w = wc.(*writer)
+	if w.w.BatchSize != 100 || w.w.Completion == nil {
+		t.Errorf("async writer batches %d lines, completion set %v", w.w.BatchSize, w.w.Completion != nil)
+	}
+}
@@ -0,0 +1,119 @@
+package golog
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Level is a log level, LEVEL_EMERGENCY to LEVEL_VERBOSE, or
+// LEVEL_EMERGENCY-1, "OFF", for a logger that logs nothing. The LEVEL_*
+// constants are untyped, so they work as both Level and int32.
+// SetLogLevel and LogLevel take and return a Level; SetLevel, GetLevel
+// and the other functions taking a level keep int32, so existing callers
+// passing int32 variables still compile.
+//
+// Level implements flag.Value, so
+//
+//	var lvl = golog.Level(golog.LEVEL_NOTICE)
+//	flag.Var(&lvl, "loglevel", "log level")
+//
+// accepts -loglevel=debug, and it marshals to its name in text and JSON.
+type Level int32
+
+var levelNames = []string{
+	"EMERGENCY",
+	"ALERT",
+	"CRITICAL",
+	"ERROR",
+	"WARNING",
+	"NOTICE",
+	"INFO",
+	"DEBUG",
+	"VERBOSE",
+}
+
+func (lvl Level) String() string {
+	if lvl == LEVEL_EMERGENCY-1 {
+		return "OFF"
+	}
+	if lvl >= 0 && int(lvl) < len(levelNames) {
+		return levelNames[lvl]
+	}
+	return fmt.Sprintf("Level(%d)", int32(lvl))
+}
+
+// parseLevel reads a level name in any case, with or without the
+// brackets of the log header ("debug", "WARNING", "[ERROR]", "[VERB]").
+func parseLevel(s string) (Level, error) {
+	name := strings.ToUpper(strings.TrimSuffix(strings.TrimPrefix(s, "["), "]"))
+	if name == "OFF" {
+		return LEVEL_EMERGENCY - 1, nil
+	}
+	for i, n := range levelNames {
+		if name == n || "["+name+"]" == levelStrings[i] {
+			return Level(i), nil
+		}
+	}
+	return 0, fmt.Errorf("golog: unknown level %q", s)
+}
+
+// ParseLevel returns the level named s, in any case and with or without
+// brackets, or numbered s ("6" for LEVEL_INFO). It returns false if s is
+// neither, so a configuration value can be checked before SetLogLevel.
+func ParseLevel(s string) (Level, bool) {
+	if lvl, err := parseLevel(s); err == nil {
+		return lvl, true
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 32)
+	if err != nil || n < 0 || int(n) >= len(levelNames) {
+		return 0, false
+	}
+	return Level(n), true
+}
+
+func (lvl Level) MarshalText() ([]byte, error) {
+	if lvl < LEVEL_EMERGENCY-1 || int(lvl) >= len(levelNames) {
+		return nil, fmt.Errorf("golog: bad level %d", int32(lvl))
+	}
+	return []byte(lvl.String()), nil
+}
+
+func (lvl *Level) UnmarshalText(text []byte) error {
+	l, err := parseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*lvl = l
+	return nil
+}
+
+func (lvl Level) MarshalJSON() ([]byte, error) {
+	text, err := lvl.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(string(text))
+}
+
+// UnmarshalJSON takes a level name or number.
+func (lvl *Level) UnmarshalJSON(data []byte) error {
+	if n, err := strconv.ParseInt(string(data), 10, 32); err == nil {
+		if n < 0 || int(n) >= len(levelNames) {
+			return fmt.Errorf("golog: bad level %d", n)
+		}
+		*lvl = Level(n)
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("golog: bad level %s", data)
+	}
+	return lvl.UnmarshalText([]byte(s))
+}
+
+// Set implements flag.Value.
+func (lvl *Level) Set(s string) error {
+	return lvl.UnmarshalText([]byte(s))
+}
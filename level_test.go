@@ -0,0 +1,132 @@
+package golog
+
+import (
+	"encoding/json"
+	"flag"
+	"io"
+	"testing"
+)
+
+func TestLevelRoundTrip(t *testing.T) {
+	for i, name := range levelNames {
+		lvl := Level(i)
+		if lvl.String() != name {
+			t.Errorf("Level(%d).String() = %q", i, lvl.String())
+		}
+
+		text, err := lvl.MarshalText()
+		if err != nil || string(text) != name {
+			t.Errorf("MarshalText(%d) = %q, %v", i, text, err)
+		}
+		var back Level
+		if err := back.UnmarshalText(text); err != nil || back != lvl {
+			t.Errorf("UnmarshalText(%q) = %v, %v", text, back, err)
+		}
+
+		data, err := json.Marshal(lvl)
+		if err != nil || string(data) != `"`+name+`"` {
+			t.Errorf("json.Marshal(%d) = %s, %v", i, data, err)
+		}
+		back = -1
+		if err := json.Unmarshal(data, &back); err != nil || back != lvl {
+			t.Errorf("json.Unmarshal(%s) = %v, %v", data, back, err)
+		}
+	}
+}
+
+func TestLevelParse(t *testing.T) {
+	for s, want := range map[string]Level{
+		"debug":     LEVEL_DEBUG,
+		"WARNING":   LEVEL_WARNING,
+		"[ERROR]":   LEVEL_ERROR,
+		"[VERB]":    LEVEL_VERBOSE,
+		"Emergency": LEVEL_EMERGENCY,
+		"off":       LEVEL_EMERGENCY - 1,
+	} {
+		var lvl Level
+		if err := lvl.UnmarshalText([]byte(s)); err != nil || lvl != want {
+			t.Errorf("UnmarshalText(%q) = %v, %v; want %v", s, lvl, err, want)
+		}
+	}
+
+	var lvl Level = LEVEL_INFO
+	for _, s := range []string{"", "warn", "[]", "LEVEL_INFO"} {
+		if err := lvl.UnmarshalText([]byte(s)); err == nil {
+			t.Errorf("UnmarshalText(%q) accepted an unknown level", s)
+		}
+	}
+	if lvl != LEVEL_INFO {
+		t.Errorf("failed UnmarshalText changed the level to %v", lvl)
+	}
+	if err := json.Unmarshal([]byte("7"), &lvl); err != nil || lvl != LEVEL_DEBUG {
+		t.Errorf("json.Unmarshal(7) = %v, %v", lvl, err)
+	}
+	for _, data := range []string{"9", "-1"} {
+		if err := json.Unmarshal([]byte(data), &lvl); err == nil || lvl != LEVEL_DEBUG {
+			t.Errorf("json.Unmarshal(%s) = %v, %v", data, lvl, err)
+		}
+	}
+	if _, err := Level(42).MarshalText(); err == nil || Level(42).String() != "Level(42)" {
+		t.Errorf("Level(42) marshals, String %q", Level(42).String())
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for s, want := range map[string]Level{
+		"info":       LEVEL_INFO,
+		"[CRITICAL]": LEVEL_CRITICAL,
+		"6":          LEVEL_INFO,
+		"0":          LEVEL_EMERGENCY,
+		"8":          LEVEL_VERBOSE,
+	} {
+		if lvl, ok := ParseLevel(s); !ok || lvl != want {
+			t.Errorf("ParseLevel(%q) = %d, %v; want %d", s, lvl, ok, want)
+		}
+	}
+	for _, s := range []string{"", "warn", "9", "-1", "6.0"} {
+		if lvl, ok := ParseLevel(s); ok {
+			t.Errorf("ParseLevel(%q) = %d, accepted", s, lvl)
+		}
+	}
+}
+
+func TestLevelFlag(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	lvl := Level(LEVEL_NOTICE)
+	fs.Var(&lvl, "loglevel", "log level")
+
+	if err := fs.Parse([]string{"-loglevel=debug"}); err != nil || lvl != LEVEL_DEBUG {
+		t.Fatalf("flag parsed to %v, %v", lvl, err)
+	}
+	if err := fs.Parse([]string{"-loglevel=chatty"}); err == nil {
+		t.Fatal("flag accepted an unknown level")
+	}
+
+	// constants and int32 levels mix.
+	captureGlobal(t, LEVEL_NOTICE)
+	SetLevel(int32(lvl))
+	if Level(GetLevel()) != LEVEL_DEBUG {
+		t.Errorf("GetLevel() = %v", GetLevel())
+	}
+}
+
+func TestSetLogLevel(t *testing.T) {
+	l := NewDiscardLogger()
+	if lvl := l.LogLevel(); lvl.String() != "OFF" {
+		t.Errorf("discard logger level %v", lvl)
+	}
+	if text, err := l.LogLevel().MarshalText(); err != nil || string(text) != "OFF" {
+		t.Errorf("off marshals to %q, %v", text, err)
+	}
+	lvl, _ := ParseLevel("warning")
+	l.SetLogLevel(lvl)
+	if l.GetLevel() != LEVEL_WARNING || l.LogLevel() != LEVEL_WARNING {
+		t.Errorf("level %d after SetLogLevel(%v)", l.GetLevel(), lvl)
+	}
+	var n int32 = LEVEL_DEBUG
+	l.SetLevel(n)
+	if l.LogLevel() != LEVEL_DEBUG {
+		t.Errorf("level %v after SetLevel(%d)", l.LogLevel(), n)
+	}
+}
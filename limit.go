@@ -0,0 +1,72 @@
+package golog
+
+import "sync/atomic"
+
+func MaxConcurrentWrites(n int) {
+	std().MaxConcurrentWrites(n)
+}
+
+// MaxConcurrentWrites lets at most n goroutines at a time format and
+// write records; the others wait for a slot or, after SetDropOnFull,
+// drop their record. Without a bound a slow output can pile up
+// thousands of goroutines on the logger lock. n <= 0 removes the bound.
+func (l *Logger) MaxConcurrentWrites(n int) {
+	l = l.root()
+	l.limitMu.Lock()
+	defer l.limitMu.Unlock()
+	if n <= 0 {
+		l.limit.Store(nil)
+		return
+	}
+	drop := false
+	if old := l.limit.Load(); old != nil {
+		drop = old.drop
+	}
+	l.limit.Store(&writeLimit{slots: make(chan struct{}, n), drop: drop})
+}
+
+func SetDropOnFull(drop bool) {
+	std().SetDropOnFull(drop)
+}
+
+// SetDropOnFull tells whether a record that finds all the slots of
+// MaxConcurrentWrites taken is dropped, and counted in DroppedCount,
+// rather than waiting. It has no effect without MaxConcurrentWrites.
+func (l *Logger) SetDropOnFull(drop bool) {
+	l = l.root()
+	l.limitMu.Lock()
+	defer l.limitMu.Unlock()
+	if old := l.limit.Load(); old != nil {
+		l.limit.Store(&writeLimit{slots: old.slots, drop: drop})
+	}
+}
+
+// DroppedCount returns the number of records dropped because all the
+// slots of MaxConcurrentWrites were taken. Unlike Dropped it is never
+// reset.
+func (l *Logger) DroppedCount() uint64 {
+	return atomic.LoadUint64(&l.root().limitDropped)
+}
+
+type writeLimit struct {
+	slots chan struct{}
+	drop  bool
+}
+
+// acquire takes a slot, it returns false if the record is to be dropped.
+func (w *writeLimit) acquire() bool {
+	if !w.drop {
+		w.slots <- struct{}{}
+		return true
+	}
+	select {
+	case w.slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (w *writeLimit) release() {
+	<-w.slots
+}
@@ -0,0 +1,64 @@
+package golog
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// stallWriter blocks every Write until release is closed.
+type stallWriter struct {
+	entered chan struct{}
+	release chan struct{}
+	mu      sync.Mutex
+	buf     bytes.Buffer
+}
+
+func (w *stallWriter) Write(p []byte) (int, error) {
+	w.entered <- struct{}{}
+	<-w.release
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func TestMaxConcurrentWrites(t *testing.T) {
+	w := &stallWriter{entered: make(chan struct{}, 8), release: make(chan struct{})}
+	l := &Logger{out: w, level: LEVEL_INFO}
+	l.MaxConcurrentWrites(1)
+	l.SetDropOnFull(true)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		l.Info("first")
+	}()
+	<-w.entered
+
+	// the only slot is taken by the stalled write.
+	if err := l.output(LEVEL_INFO, "second"); err != ErrDropped {
+		t.Errorf("output() = %v, want ErrDropped", err)
+	}
+	if n := l.DroppedCount(); n != 1 {
+		t.Errorf("DroppedCount() = %d, want 1", n)
+	}
+	close(w.release)
+	<-done
+
+	l.Info("third")
+	<-w.entered
+	got := w.buf.String()
+	if !strings.Contains(got, "first") || strings.Contains(got, "second") || !strings.Contains(got, "third") {
+		t.Errorf("got:\n%s", got)
+	}
+
+	// waiting instead of dropping, and no bound at all.
+	l.SetDropOnFull(false)
+	l.Info("fourth")
+	l.MaxConcurrentWrites(0)
+	l.Info("fifth")
+	if n := l.DroppedCount(); n != 1 || !strings.Contains(w.buf.String(), "fifth") {
+		t.Errorf("DroppedCount() = %d, got:\n%s", n, w.buf.String())
+	}
+}
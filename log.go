@@ -1,18 +1,53 @@
 package golog
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"os"
 	"path/filepath"
 	"runtime"
 	"runtime/debug"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 )
 
+// header flags, see SetFlags.
+const (
+	Lpackagefile       = 1 << iota // caller as pkg/file.go:12 rather than file.go:12
+	LstdlibLevelPrefix             // with StyleStdlib, start messages with "ERROR: " and the like
+	Ldelta                         // time since the previous record after the timestamp, as +12.3ms
+)
+
+// A HeaderStyle is the layout of record headers, see SetHeaderStyle.
+type HeaderStyle int
+
+const (
+	// 2015-05-14 09:56:00.023132 [DEBUG] file.go:12: message
+	StyleGolog HeaderStyle = iota
+	// 2015/05/14 09:56:00.023132 file.go:12: message, as written by the
+	// log package with Ldate|Ltime|Lmicroseconds|Lshortfile
+	StyleStdlib
+)
+
+// A HeaderField is a part of record headers, see SetHeaderFields.
+type HeaderField int
+
+const (
+	HeaderTime   HeaderField = iota // 2015-05-14 09:56:00.023132, with Ldelta
+	HeaderLevel                     // [DEBUG], not in StyleStdlib
+	HeaderCaller                    // file.go:12:
+)
+
+// HeaderFields lists the header fields to write, in order.
+type HeaderFields []HeaderField
+
+var defaultHeaderFields = HeaderFields{HeaderTime, HeaderLevel, HeaderCaller}
+
 // RFC5424
 const (
 	LEVEL_EMERGENCY = iota
@@ -40,67 +75,530 @@ var (
 	}
 )
 
+// appendLevel appends the level token, e.g. "[INFO]", or the number in
+// brackets for a level that has no name.
+func appendLevel(buf []byte, level int32) []byte {
+	if level >= 0 && int(level) < len(levelStrings) {
+		return append(buf, levelStrings[level]...)
+	}
+	buf = append(buf, '[')
+	buf = strconv.AppendInt(buf, int64(level), 10)
+	return append(buf, ']')
+}
+
 // A Logger represents an active logging object that generates lines of
 // output to an io.Writer.  Each logging operation makes a single call to
 // the Writer's Write method.  A Logger can be used simultaneously from
 // multiple goroutines; it guarantees to serialize access to the Writer.
 type Logger struct {
 	level        int32
-	mu           sync.Mutex // ensures atomic writes; protects the following fields
-	out          *os.File   // destination for output
-	path         string     // log file path
-	buf          []byte     // for accumulating text to write
+	rotateMu     sync.Mutex    // serializes rotations, taken before mu
+	inflight     sync.RWMutex  // read locked by writes to out done without mu
+	mu           sync.RWMutex  // ensures atomic writes; protects the following fields
+	out          io.Writer     // destination for output
+	file         *FileSink     // the log file out writes to, nil if none
+	eol          string        // line terminator, "" means "\n"
+	journal      *journal      // send records to journald instead of out
+	sink         Sink          // replaces out, see SetSink
+	sinks        []Sink        // written besides out, see AddSink
+	sinkMu       sync.Mutex    // serializes writes to sinks, see writeSinks
+	maxLines     int64         // rotate after this many lines, see SetMaxLines
+	lines        int64         // lines written to the current file
+	syncWrites   bool          // fsync out after every record, see Audit
+	atomicWrite  bool          // see SetAtomicWrite
+	rotator      *rotator      // set by EnableRotate
+	rotatePeriod time.Duration // set by EnableRotate, for MarshalConfig
+	saveTime     time.Duration // see SetFileMaxAge
+	multiline    MultilineMode
+	contPrefix   string // continuation line marker, see SetContinuationPrefix
+	quote        bool   // see SetQuoteMessages
+	flags        int    // L* header flags
+	pathPrefix   string // stripped from caller paths, see SetPathPrefix
+	style        HeaderStyle
+	headerFields HeaderFields
+	buf          []byte // for accumulating text to write
+	lastRecord   int64  // monotonic time of the previous record, see Ldelta
 	microseconds bool
 	shortfile    bool
+
+	writeTimeout   time.Duration     // see SetWritePolicy
+	queue          chan queuedRecord // records handed to the writer goroutine
+	dropped        uint64            // records dropped since the last summary
+	pending        int64             // records queued and not written yet, see drain
+	queueFullSince int64             // unix nanoseconds, 0 unless the queue is full
+	batchRecords   int               // see SetBatch, 0 until the queue is set up
+	batchBytes     int
+	batchDelay     time.Duration
+
+	health   health   // write error tracking, see Stats
+	counters counters // see Counters
+
+	filterMu sync.Mutex               // serializes AddFilter, SetLevelForPath and removal
+	filters  atomic.Pointer[[]filter] // copy on write, read without a lock
+	filtered uint64                   // records dropped by filters
+
+	pathLevels atomic.Pointer[[]pathLevel] // copy on write, see SetLevelForPath
+
+	onError atomic.Pointer[func(op string, err error)] // see OnError
+	metrics atomic.Pointer[Metrics]                    // see EnableMetrics
+
+	parent     *Logger // a child logs through parent, see AddCallerSkipFrames
+	callerSkip int
+	fanout     []target // records go to these instead, see NewMultiLogger
+	sampler    *sampler // see NewSampledLogger
+
+	prefix string   // put before the messages of a child, see NewPrefixLogger
+	scope  []*field // appended to the messages of a child, see WithScope
+
+	verbosity int32 // see SetVerbosity
+	panicOn   int32 // level+1 records panic from, 0 is off, see EnablePanicOnLevel
+
+	early        []earlyRecord // see BufferUntilConfigured
+	earlyMax     int
+	earlyDropped int
+
+	closed bool // out fell back to stderr, the notice is not written yet
+
+	raw RawRecord // see SetRawRecord
+
+	defaults     []field // see SetDefaultFields, sorted by key
+	defaultsText string  // defaults formatted, appended to every message
+
+	drainOnce sync.Once // see DrainAndClose
+	drainErr  error
+
+	tempMu    sync.Mutex   // protects the following fields, see TempLevel
+	tempBase  int32        // the level set by SetLevel while overridden
+	tempStack []*tempLevel // active overrides, the last one applies
+
+	limitMu      sync.Mutex                 // serializes MaxConcurrentWrites and SetDropOnFull
+	limit        atomic.Pointer[writeLimit] // nil unless MaxConcurrentWrites
+	limitDropped uint64                     // see DroppedCount
 }
 
 /*
- * global static var
+ * global static var, used by the package level functions
  */
-var _log = &Logger{
-	out:          os.Stderr,
-	level:        LEVEL_NOTICE,
-	microseconds: true,
-	shortfile:    true,
+var global atomic.Pointer[Logger]
+
+func init() {
+	global.Store(&Logger{
+		out:          os.Stderr,
+		level:        LEVEL_NOTICE,
+		microseconds: true,
+		shortfile:    true,
+	})
 }
 
-var saveTime time.Duration = 0 * time.Second
+func std() *Logger {
+	return global.Load()
+}
+
+// SetGlobalLogger makes the package level functions (Info, SetLevel,
+// SetFile, ...) use l, so code that only knows the package functions,
+// e.g. libraries, logs the way the application configured l. l must not
+// be nil.
+func SetGlobalLogger(l *Logger) {
+	global.Store(l)
+}
+
+// GetGlobalLogger returns the logger used by the package level functions.
+func GetGlobalLogger() *Logger {
+	return std()
+}
+
+// Default returns the global logger, to configure it through its methods,
+// e.g. golog.Default().SetLevel(golog.LEVEL_DEBUG), or to hand it to code
+// that takes a *Logger. It is the same as GetGlobalLogger.
+func Default() *Logger {
+	return std()
+}
+
+// NewDiscardLogger returns a Logger that drops everything. Its level is
+// below LEVEL_EMERGENCY, so every call returns right after the level
+// check without formatting or allocating.
+func NewDiscardLogger() *Logger {
+	return &Logger{
+		out:   io.Discard,
+		level: LEVEL_EMERGENCY - 1,
+	}
+}
+
+func SetLogLevel(lvl Level) {
+	Critical("set log level to %v", int32(lvl))
+	std().setLevel(int32(lvl))
+}
+
+func LogLevel() Level {
+	return std().LogLevel()
+}
+
+// SetLogLevel makes l log the records at lvl and more severe ones.
+func (l *Logger) SetLogLevel(lvl Level) {
+	l.root().setLevel(int32(lvl))
+}
 
+func (l *Logger) LogLevel() Level {
+	return Level(atomic.LoadInt32(&l.root().level))
+}
+
+// SetLevel is SetLogLevel for int32 levels.
 func SetLevel(level int32) {
-	Critical("set log level to %v", level)
-	atomic.StoreInt32(&_log.level, level)
+	SetLogLevel(Level(level))
 }
 
+// GetLevel is LogLevel as an int32.
 func GetLevel() int32 {
-	v := atomic.LoadInt32(&_log.level)
-	return v
+	return int32(LogLevel())
+}
+
+func (l *Logger) SetLevel(level int32) {
+	l.SetLogLevel(Level(level))
+}
+
+func (l *Logger) GetLevel() int32 {
+	return int32(l.LogLevel())
+}
+
+// IsLevelEnabled tells whether messages at level are logged, so callers
+// can skip building expensive arguments.
+func IsLevelEnabled(level int32) bool {
+	return std().IsLevelEnabled(level)
 }
 
+func (l *Logger) IsLevelEnabled(level int32) bool {
+	l = l.root()
+	if l.fanout != nil {
+		return l.anyEnabled(level)
+	}
+	return level <= atomic.LoadInt32(&l.level)
+}
+
+func IsErrorEnabled() bool   { return IsLevelEnabled(LEVEL_ERROR) }
+func IsWarnEnabled() bool    { return IsLevelEnabled(LEVEL_WARNING) }
+func IsNoticeEnabled() bool  { return IsLevelEnabled(LEVEL_NOTICE) }
+func IsInfoEnabled() bool    { return IsLevelEnabled(LEVEL_INFO) }
+func IsDebugEnabled() bool   { return IsLevelEnabled(LEVEL_DEBUG) }
+func IsVerboseEnabled() bool { return IsLevelEnabled(LEVEL_VERBOSE) }
+
 func SetFile(path string) {
+	SetFileWithPerm(path, defaultPerm)
+}
+
+// SetFileWithPerm is SetFile creating the file, and the files ReOpen and
+// rotation create after it, with permission perm (before the umask).
+func SetFileWithPerm(path string, perm os.FileMode) {
 	//Critical("set log file to %v", path)
-	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_RDWR, 0666)
+	s, err := openFileSink(path, false, perm)
 	if err != nil {
 		Error("error on SetLogFile: err: %s", err)
+		return
 	}
 
-	_log.out = f
-	_log.path = path
+	std().setFile(s)
 }
 
-func ReOpen(path string) {
-	if _log.path == "" {
+// SetOutput sends the log to w, e.g. a network writer. The log is no
+// longer associated with a file, so ReOpen does nothing.
+func SetOutput(w io.Writer) {
+	std().replaceOut(w, nil)
+}
+
+// setFile makes the file of s the output.
+func (l *Logger) setFile(s *FileSink) {
+	l.replaceOut(fileWriter{s}, s)
+}
+
+// replaceOut makes w, writing to file unless that is nil, the output.
+// The file, journal or sink used before is closed once the writes to it
+// still under way are done.
+func (l *Logger) replaceOut(w io.Writer, file *FileSink) {
+	l.mu.Lock()
+	old := l.detach()
+	destLock(w) // make the lock of w now, not on the first write
+	l.out = w
+	l.file = file
+	l.lines = 0
+	l.closed = false
+	if l.buf == nil {
+		l.buf = make([]byte, 0, defaultBufferSize)
+	}
+	l.replayEarly()
+	l.mu.Unlock()
+
+	l.retire(old)
+}
+
+// the record buffer is allocated with this capacity when an output is
+// set, see SetBufferSize.
+const defaultBufferSize = 512
+
+func SetBufferSize(n int) {
+	std().SetBufferSize(n)
+}
+
+// SetBufferSize allocates the buffer records are formatted in with room
+// for n bytes, so that records up to that size never make a log call
+// grow it. SetFile and SetOutput allocate 512 bytes. The buffer still
+// grows for a longer record, and keeps that size.
+func (l *Logger) SetBufferSize(n int) {
+	if n < 0 {
+		n = 0
+	}
+	l = l.root()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.buf = make([]byte, 0, n)
+}
+
+// retire closes outputs that are no longer used, see detach, after the
+// writes to them that started before they were replaced are done. l.mu
+// must not be held.
+func (l *Logger) retire(old []io.Closer) {
+	if len(old) == 0 {
 		return
 	}
-	_log.mu.Lock()
-	defer _log.mu.Unlock()
+	l.inflight.Lock()
+	l.inflight.Unlock()
+	for _, c := range old {
+		c.Close()
+	}
+}
+
+// filePath returns the log file path, "" if the log is not a file.
+func (l *Logger) filePath() string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.file.Path()
+}
+
+// IsFileLogger tells whether l writes to a file it opened itself, with
+// SetFile and the like, so it can be reopened and rotated. A file passed
+// to SetOutput, like os.Stderr, does not count: its path is unknown.
+func (l *Logger) IsFileLogger() bool {
+	return l.FilePath() != ""
+}
 
-	_log.out.Close()
-	SetFile(_log.path)
+// FilePath returns the path of the file l writes to, "" unless
+// IsFileLogger.
+func (l *Logger) FilePath() string {
+	return l.root().filePath()
 }
 
-func timestr(period time.Duration) string {
-	t := time.Now().Add(time.Second * -10)
+func ReOpen(path string) {
+	l := std()
+	err := l.reopen()
+	l.noteError("reopen", err)
+	l.mu.Lock()
+	if serr := l.reopenSinks(); err == nil {
+		err = serr
+	}
+	l.mu.Unlock()
+
+	if err != nil {
+		Error("error on ReOpen: err: %s", err)
+	}
+}
+
+// reopen opens the log file again, e.g. after logrotate moved it away,
+// see FileSink.Reopen. Logging does not stall meanwhile. If the file can
+// not be opened the old one is kept.
+func (l *Logger) reopen() error {
+	l.mu.RLock()
+	s := l.file
+	l.mu.RUnlock()
+	if s == nil {
+		return nil
+	}
+	// a file replaced meanwhile is closed, Reopen leaves it so.
+	if err := s.Reopen(); err != nil {
+		return err
+	}
+	// writing to stderr after a failed rotation.
+	l.mu.Lock()
+	if l.file == s {
+		l.out = fileWriter{s}
+	}
+	l.mu.Unlock()
+	return nil
+}
+
+// openFile makes path, opened for appending, the output of l, for tests.
+func (l *Logger) openFile(path string) error {
+	s, err := openFileSink(path, false, defaultPerm)
+	if err != nil {
+		return err
+	}
+	l.setFile(s)
+	return nil
+}
+
+// defaultPerm is the permission log files are created with, before the
+// umask.
+const defaultPerm os.FileMode = 0666
+
+// openFile opens path for appending, as a gzip stream if compress is set,
+// creating it with perm, or defaultPerm if perm is 0.
+func openFile(path string, compress bool, perm os.FileMode) (io.Writer, error) {
+	if perm == 0 {
+		perm = defaultPerm
+	}
+	if compress {
+		return newGzipFile(path, perm)
+	}
+	return os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_RDWR, perm)
+}
+
+// renameOpenFiles tells whether the log file can be renamed while it is
+// still open, which windows does not allow.
+const renameOpenFiles = runtime.GOOS != "windows"
+
+// rotate renames the log file to filename, or the first free name
+// after it (see freeName), and opens a new one, see FileSink.rotate.
+// l.mu is not held meanwhile, so logging does not stall: lines logged
+// during the rotation go to the old file, now called filename.
+func (l *Logger) rotate(filename string) error {
+	l.rotateMu.Lock()
+	defer l.rotateMu.Unlock()
+
+	l.mu.RLock()
+	file := l.file
+	l.mu.RUnlock()
+	if file == nil {
+		return nil
+	}
+	filename, err := freeName(filename)
+	if err != nil {
+		return err
+	}
+	renameErr, openErr := file.rotate(filename)
+
+	l.mu.Lock()
+	if l.file == file {
+		l.rotated(renameErr, openErr)
+	}
+	l.mu.Unlock()
+	if openErr != nil {
+		return openErr
+	}
+	return renameErr
+}
 
+// rotated counts a rotation of l.file; if no new file could be opened
+// the log goes to stderr until ReOpen. l.mu must be held.
+func (l *Logger) rotated(renameErr, openErr error) {
+	l.lines = 0
+	if renameErr == nil {
+		atomic.AddUint64(&l.counters.rotations, 1)
+	}
+	if openErr != nil {
+		l.out = os.Stderr
+	}
+}
+
+// rotateLocked is rotate with l.mu held, for rotations triggered while
+// writing a line.
+func (l *Logger) rotateLocked(filename string) error {
+	if l.file == nil {
+		return nil
+	}
+	filename, err := freeName(filename)
+	if err != nil {
+		return err
+	}
+	renameErr, openErr := l.file.rotate(filename)
+	l.rotated(renameErr, openErr)
+	if openErr != nil {
+		return openErr
+	}
+	return renameErr
+}
+
+// SetMaxLines rotates the log file after every n lines, n <= 0 turns
+// it off. Rotated files are named after the time of rotation, down to
+// the second. This works alongside EnableRotate: a time based rotation
+// starts a new count.
+//
+// Lines are counted when they are written, so with DropWithTimeout
+// (which writes from another goroutine) the limit is not applied.
+func SetMaxLines(n int64) {
+	std().SetMaxLines(n)
+}
+
+func (l *Logger) SetMaxLines(n int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.maxLines = n
+	l.lines = 0
+}
+
+// countLine counts a written line and rotates when the file is full,
+// l.mu must be held.
+func (l *Logger) countLine(now time.Time) error {
+	if l.maxLines <= 0 || l.file == nil {
+		return nil
+	}
+	l.lines++
+	if l.lines < l.maxLines {
+		return nil
+	}
+
+	// a time based rotation is under way and will start a new count.
+	if !l.rotateMu.TryLock() {
+		return nil
+	}
+	defer l.rotateMu.Unlock()
+
+	err := l.rotateLocked(fmt.Sprintf("%s.%s", l.file.path, timestr(now, 0)))
+	l.recordError("rotate", err)
+	if err != nil {
+		return err
+	}
+	go l.deleteExpiredLog()
+	return nil
+}
+
+// Close closes the log file, flushing a compressed stream, and the
+// sinks. Records logged afterwards go to stderr.
+func Close() error {
+	return std().Close()
+}
+
+// Close closes the log file and the sinks of l. The output is switched
+// to stderr before the file is closed, so records logged meanwhile or
+// afterwards are not lost; the first of them is preceded by a notice.
+func (l *Logger) Close() error {
+	l = l.root()
+	l.mu.Lock()
+	old := l.out
+	l.out = os.Stderr
+	l.file = nil
+	l.lines = 0
+	sinks := l.allSinks()
+	l.sink, l.sinks = nil, nil
+	c, ok := old.(io.Closer)
+	ok = ok && old != os.Stderr
+	l.closed = ok
+	l.mu.Unlock()
+
+	// wait for the writes under way, the writer goroutine may be
+	// writing to the sinks too.
+	l.inflight.Lock()
+	l.inflight.Unlock()
+	var err error
+	if ok {
+		err = c.Close()
+	}
+	for _, s := range sinks {
+		if serr := s.Close(); err == nil {
+			err = serr
+		}
+	}
+	return err
+}
+
+// timestr names the file covering the period that starts at t.
+func timestr(t time.Time, period time.Duration) string {
 	if period == time.Minute {
 		return fmt.Sprintf("%04d%02d%02d%02d%02d",
 			t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute())
@@ -118,95 +616,532 @@ func timestr(period time.Duration) string {
 		t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second())
 }
 
+const week = 7 * 24 * time.Hour
+
+var rotateWeekday atomic.Int32 // days from Monday to the first day of the week
+
+// SetRotateWeekday sets the day weekly (and multi-week) rotation
+// periods start on. The default is Monday.
+func SetRotateWeekday(day time.Weekday) {
+	rotateWeekday.Store(int32((day - time.Monday + 7) % 7))
+}
+
+var rotateLocation atomic.Pointer[time.Location]
+
+// SetRotateLocation sets the time zone whose midnight starts the periods
+// of EnableRotate when they are whole days. The default, nil, is
+// time.Local.
+func SetRotateLocation(loc *time.Location) {
+	rotateLocation.Store(loc)
+}
+
+const oneDay = 24 * time.Hour
+
+// periodStart returns the start of the rotate period containing t.
+// Periods shorter than a day are aligned with Truncate. Periods of whole
+// days start at midnight in t's location, counted on the calendar from
+// 0001-01-01, a Monday, so weeks start on Monday unless moved by
+// SetRotateWeekday.
+func periodStart(t time.Time, period time.Duration) time.Time {
+	if period%oneDay != 0 {
+		return t.Truncate(period)
+	}
+	days := int64(period / oneDay)
+	y, m, d := t.Date()
+	n := time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix()/86400 + unixToCivilDays
+	offset := int64(0)
+	if period%week == 0 {
+		offset = int64(rotateWeekday.Load())
+	}
+	start := (n-offset)/days*days + offset
+	return time.Date(1970, 1, 1+int(start-unixToCivilDays), 0, 0, 0, 0, t.Location())
+}
+
+// days from 0001-01-01 to 1970-01-01.
+const unixToCivilDays = 719162
+
+// periodEnd returns the start of the rotate period after the one
+// containing t. Days are counted on the calendar, so a period may be an
+// hour shorter or longer across a DST change.
+func periodEnd(t time.Time, period time.Duration) time.Time {
+	start := periodStart(t, period)
+	if period%oneDay != 0 {
+		return start.Add(period)
+	}
+	y, m, d := start.Date()
+	return time.Date(y, m, d+int(period/oneDay), 0, 0, 0, 0, start.Location())
+}
+
+// errNoFile is returned when rotation is enabled without a log file.
+var errNoFile = errors.New("golog: no log file to rotate, see SetFile")
+
 /*
  * enable rotate whit peirod
- * peirod can be: time.Minute, time.Hour, 24 * time.Hour
+ * peirod can be any whole number of minutes, e.g. time.Hour,
+ * 6 * time.Hour or 7 * 24 * time.Hour for weekly files.
+ * calling it again changes the period, see SetRotatePeriod. SetFile
+ * must be called first.
  */
-func EnableRotate(period time.Duration) {
-	if period != time.Minute && period != time.Hour && period != time.Hour*24 {
-		Error("bad rotate peirod: %s", period)
-		return
+func EnableRotate(period time.Duration) error {
+	return std().enableRotate(period)
+}
+
+func (l *Logger) enableRotate(period time.Duration) error {
+	if period < time.Minute || period%time.Minute != 0 {
+		return fmt.Errorf("golog: bad rotate period %v, want a whole number of minutes", period)
+	}
+	if l.filePath() == "" {
+		return errNoFile
 	}
 
-	ch := make(chan bool)
+	next, suffix := periodSchedule(period)
+	l.startRotate(period, next, suffix)
+	return nil
+}
 
-	go func() {
-		for {
-			now := time.Now()
-			nextRotateTime := now.Truncate(period).Add(period).Add(time.Second)
-			timer := time.NewTimer(nextRotateTime.Sub(now))
-			<-timer.C
-			ch <- true
-		}
-	}()
-
-	go func() {
-		for {
-			<-ch
-			filename := fmt.Sprintf("%s.%s", _log.path, timestr(period))
-			os.Rename(_log.path, filename)
-			ReOpen(_log.path)
-			go deleteExpiredLog(period)
+// periodSchedule returns the boundaries and file suffixes of rotation
+// every period.
+func periodSchedule(period time.Duration) (next func(now time.Time) time.Time,
+	suffix func(boundary time.Time) string) {
+
+	loc := rotateLocation.Load()
+	if loc == nil {
+		loc = time.Local
+	}
+	next = func(now time.Time) time.Time {
+		return periodEnd(now.In(loc), period)
+	}
+	suffix = func(boundary time.Time) string {
+		return timestr(periodStart(boundary.Add(-time.Nanosecond), period), period)
+	}
+	return next, suffix
+}
+
+/*
+ * enable daily rotate at a wall clock time in loc,
+ * at is the offset from midnight, e.g. 3*time.Hour for 03:00.
+ * a nil loc means time.Local.
+ */
+func EnableRotateDaily(at time.Duration, loc *time.Location) error {
+	if at < 0 || at >= 24*time.Hour {
+		return fmt.Errorf("golog: bad daily rotate time %v", at)
+	}
+	if std().filePath() == "" {
+		return errNoFile
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+
+	std().startRotate(24*time.Hour,
+		func(now time.Time) time.Time {
+			return nextDailyRotate(now, at, loc)
+		},
+		func(boundary time.Time) string {
+			return dailySuffix(boundary, loc)
+		})
+	return nil
+}
+
+// nextDailyRotate returns the first time after now whose wall clock in
+// loc is midnight+at. Days are counted on the calendar, not as 24h
+// durations, so DST changes keep the wall clock time.
+func nextDailyRotate(now time.Time, at time.Duration, loc *time.Location) time.Time {
+	h := int(at / time.Hour)
+	m := int(at % time.Hour / time.Minute)
+	s := int(at % time.Minute / time.Second)
+
+	t := now.In(loc)
+	next := time.Date(t.Year(), t.Month(), t.Day(), h, m, s, 0, loc)
+	if !next.After(now) {
+		next = time.Date(t.Year(), t.Month(), t.Day()+1, h, m, s, 0, loc)
+	}
+	return next
+}
+
+// dailySuffix names the file rotated at boundary after the day it
+// started on, in loc.
+func dailySuffix(boundary time.Time, loc *time.Location) string {
+	t := boundary.In(loc)
+	t = time.Date(t.Year(), t.Month(), t.Day()-1, 12, 0, 0, 0, loc)
+	return fmt.Sprintf("%04d%02d%02d", t.Year(), t.Month(), t.Day())
+}
+
+// startRotate rotates the log file with the given schedule, see
+// rotator. Called again, it changes the schedule of the running rotation.
+func (l *Logger) startRotate(period time.Duration, next func(now time.Time) time.Time,
+	suffix func(boundary time.Time) string) {
+
+	l.getRotator().retarget(period, next, suffix)
+}
+
+// getRotator returns the rotator of l, creating a stopped one.
+func (l *Logger) getRotator() *rotator {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.rotator == nil {
+		l.rotator = &rotator{l: l, clock: rotateClock, report: l.noteError}
+	}
+	return l.rotator
+}
+
+// rotateStale moves away a log file left over from an earlier period,
+// e.g. by a restart, so it is not named after the current period on the
+// next rotation. Empty files are kept and reused. It returns the error
+// of the rotation, if any.
+func (l *Logger) rotateStale(now time.Time, next func(now time.Time) time.Time,
+	suffix func(boundary time.Time) string) error {
+
+	path := l.filePath()
+	if path == "" {
+		return nil
+	}
+	fi, err := os.Stat(path)
+	if err != nil || fi.Size() == 0 {
+		return nil
+	}
+	boundary := next(fi.ModTime())
+	if boundary.After(now) {
+		return nil
+	}
+
+	return l.rotate(fmt.Sprintf("%s.%s", path, suffix(boundary)))
+}
+
+// max number of -N suffixes tried by freeName.
+const maxNameCollisions = 100
+
+// freeName returns name, or name-1, name-2 ... if name already exists.
+func freeName(name string) (string, error) {
+	candidate := name
+	for i := 1; i <= maxNameCollisions; i++ {
+		if _, err := os.Lstat(candidate); os.IsNotExist(err) {
+			return candidate, nil
 		}
-	}()
+		candidate = fmt.Sprintf("%s-%d", name, i)
+	}
+	return "", fmt.Errorf("golog: no free name for %s", name)
 }
 
+// SetLogSaveTime is SetFileMaxAge.
 func SetLogSaveTime(period time.Duration) {
-	saveTime = period
+	SetFileMaxAge(period)
+}
+
+func SetFileMaxAge(d time.Duration) {
+	std().SetFileMaxAge(d)
+}
+
+// SetFileMaxAge makes rotation delete the rotated files of l that were
+// last written more than d ago. 0, the default, keeps them all. Each
+// logger has its own setting, so e.g. audit files can be kept longer
+// than the main log.
+func (l *Logger) SetFileMaxAge(d time.Duration) {
+	l = l.root()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.saveTime = d
 }
 
-func deleteExpiredLog(period time.Duration) {
-	dirName := filepath.Dir(_log.path)
-	logName := filepath.Base(_log.path)
-	fileInfos, err := ioutil.ReadDir(dirName)
+// deleteExpiredLog removes the rotated files older than the max age of l.
+func (l *Logger) deleteExpiredLog() {
+	l.mu.RLock()
+	path, saveTime := l.file.Path(), l.saveTime
+	l.mu.RUnlock()
+	dirName := filepath.Dir(path)
+	logName := filepath.Base(path)
+	entries, err := os.ReadDir(dirName)
 	if err != nil {
-		Warn("read dir %s fail, err is %v", dirName, err)
+		l.noteError("cleanup", fmt.Errorf("read dir %s: %w", dirName, err))
 	}
 
-	for _, fileInfo := range fileInfos {
+	for _, entry := range entries {
+		fileInfo, err := entry.Info()
+		if err != nil {
+			// removed since we read the dir
+			continue
+		}
 		fileName := fileInfo.Name()
 		mtime := fileInfo.ModTime()
 		if saveTime != 0*time.Second &&
 			strings.Index(fmt.Sprintf("%s.", fileName), logName) == 0 &&
 			time.Now().Sub(mtime) >= saveTime {
-			os.Remove(fmt.Sprintf("%s/%s", dirName, fileName))
+			if err := os.Remove(fmt.Sprintf("%s/%s", dirName, fileName)); err != nil && !os.IsNotExist(err) {
+				l.noteError("cleanup", err)
+			}
 		}
 	}
 }
 
+var (
+	emergencyExitCode int32 = 2
+	exit                    = os.Exit // replaced in tests
+)
+
+// SetEmergencyExitCode sets the exit status of Emergency, 2 by default.
+func SetEmergencyExitCode(code int) {
+	atomic.StoreInt32(&emergencyExitCode, int32(code))
+}
+
+// Emergency logs at LEVEL_EMERGENCY and stops the process: the system
+// is unusable. The log is drained and closed first, see DrainAndClose,
+// waiting at most a second, and the process exits with the code set by
+// SetEmergencyExitCode.
+func Emergency(format string, v ...interface{}) {
+	l := std()
+	l.output(LEVEL_EMERGENCY, format, v...)
+	l.DrainAndClose(time.Second)
+	exit(int(atomic.LoadInt32(&emergencyExitCode)))
+}
+
 func Critical(format string, v ...interface{}) {
-	_log.output(LEVEL_CRITICAL, format, v...)
+	std().output(LEVEL_CRITICAL, format, v...)
 }
 
 func Error(format string, v ...interface{}) {
-	_log.output(LEVEL_ERROR, format, v...)
+	std().output(LEVEL_ERROR, format, v...)
 }
 
 func Warn(format string, v ...interface{}) {
-	_log.output(LEVEL_WARNING, format, v...)
+	std().output(LEVEL_WARNING, format, v...)
 }
 
 func Notice(format string, v ...interface{}) {
-	_log.output(LEVEL_NOTICE, format, v...)
+	std().output(LEVEL_NOTICE, format, v...)
 }
 
 func Info(format string, v ...interface{}) {
-	_log.output(LEVEL_INFO, format, v...)
+	std().output(LEVEL_INFO, format, v...)
 }
 
 func Debug(format string, v ...interface{}) {
-	_log.output(LEVEL_DEBUG, format, v...)
+	std().output(LEVEL_DEBUG, format, v...)
 }
 
 func Verbose(format string, v ...interface{}) {
-	_log.output(LEVEL_VERBOSE, format, v...)
+	std().output(LEVEL_VERBOSE, format, v...)
+}
+
+func (l *Logger) Critical(format string, v ...interface{}) {
+	l.output(LEVEL_CRITICAL, format, v...)
+}
+
+func (l *Logger) Error(format string, v ...interface{}) {
+	l.output(LEVEL_ERROR, format, v...)
+}
+
+func (l *Logger) Warn(format string, v ...interface{}) {
+	l.output(LEVEL_WARNING, format, v...)
+}
+
+func (l *Logger) Notice(format string, v ...interface{}) {
+	l.output(LEVEL_NOTICE, format, v...)
+}
+
+func (l *Logger) Info(format string, v ...interface{}) {
+	l.output(LEVEL_INFO, format, v...)
+}
+
+func (l *Logger) Debug(format string, v ...interface{}) {
+	l.output(LEVEL_DEBUG, format, v...)
+}
+
+func (l *Logger) Verbose(format string, v ...interface{}) {
+	l.output(LEVEL_VERBOSE, format, v...)
+}
+
+/*
+ * Println style functions, the operands are separated by spaces
+ */
+func Criticalln(v ...interface{}) {
+	std().outputln(LEVEL_CRITICAL, v)
+}
+
+func Errorln(v ...interface{}) {
+	std().outputln(LEVEL_ERROR, v)
+}
+
+func Warnln(v ...interface{}) {
+	std().outputln(LEVEL_WARNING, v)
+}
+
+func Noticeln(v ...interface{}) {
+	std().outputln(LEVEL_NOTICE, v)
+}
+
+func Infoln(v ...interface{}) {
+	std().outputln(LEVEL_INFO, v)
+}
+
+func Debugln(v ...interface{}) {
+	std().outputln(LEVEL_DEBUG, v)
+}
+
+func Verboseln(v ...interface{}) {
+	std().outputln(LEVEL_VERBOSE, v)
+}
+
+// outputln formats v with fmt.Sprintln; its newline ends the record.
+func (l *Logger) outputln(level int32, v []interface{}) error {
+	if !l.enabledAt(2, level) {
+		return nil
+	}
+	return l.outputDepth(3, level, "", "%s", fmt.Sprintln(v...))
+}
+
+// Write logs p, without its trailing newline, at LEVEL_INFO, so a Logger
+// can go where an io.Writer is expected, e.g. log.New(golog.Default(),
+// "", 0) for net/http.Server.ErrorLog. The caller of Write is reported
+// as the source.
+func (l *Logger) Write(p []byte) (int, error) {
+	if err := l.outputDepth(2, LEVEL_INFO, "", "%s", bytes.TrimSuffix(p, []byte("\n"))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// OutputTo writes a record formatted by l to w instead of the output of
+// l, for a one-off destination such as an audit log. The level of l
+// applies; filters, sinks and the counters of l do not. w is written
+// after l is unlocked, so a slow w holds up only the caller.
+func (l *Logger) OutputTo(level int32, w io.Writer, format string, v ...interface{}) error {
+	if !l.IsLevelEnabled(level) {
+		return nil
+	}
+	now := time.Now().Round(0)
+	ci, ok := callerSite(1 + l.callerSkip)
+	if !ok {
+		ci = callerInfo{file: "???"}
+	}
+
+	root := l.root()
+	root.mu.RLock()
+	var rec []byte
+	root.formatHeader(&rec, now, level, ci)
+	n := len(rec)
+	rec = fmt.Appendf(rec, format, v...)
+	rec = root.appendContext(rec, joinScope(goroutineScope(), l.scope))
+	rec = foldLines(rec, n, root.multiline, root.contPrefix)
+	if root.quote {
+		rec = quoteMessage(rec, n)
+	}
+	rec = terminate(rec, n, root.eol)
+	root.mu.RUnlock()
+
+	_, err := writeDest(w, rec)
+	return err
+}
+
+type printfLogger struct {
+	level int32
+}
+
+func (p printfLogger) Printf(format string, v ...interface{}) {
+	std().output(p.level, format, v...)
+}
+
+// PrintfLogger adapts the global logger to libraries that take a
+// Printf-style logger; lines are logged at LEVEL_INFO.
+func PrintfLogger() interface{ Printf(string, ...interface{}) } {
+	return printfLogger{LEVEL_INFO}
+}
+
+// PrintfLoggerAt is like PrintfLogger but logs at the given level.
+func PrintfLoggerAt(level int32) interface{ Printf(string, ...interface{}) } {
+	return printfLogger{level}
+}
+
+// LeveledWriter is an io.Writer logging every write to the global
+// logger at its level, like Logger.Write does at LEVEL_INFO.
+type LeveledWriter struct {
+	level int32
+}
+
+// NewLeveledWriter returns a LeveledWriter for level, e.g. for
+//
+//	http.Server{ErrorLog: log.New(golog.NewLeveledWriter(golog.LEVEL_ERROR), "", 0)}
+func NewLeveledWriter(level int32) io.Writer {
+	return LeveledWriter{level}
+}
+
+func (w LeveledWriter) Write(p []byte) (int, error) {
+	if err := std().outputDepth(2, w.level, "", "%s", bytes.TrimSuffix(p, []byte("\n"))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
 }
 
 func Stacktrace(level int32, format string, v ...interface{}) {
-	if level > GetLevel() {
+	stacktrace(level, format, v...)
+}
+
+func StacktraceCritical(format string, v ...interface{}) {
+	stacktrace(LEVEL_CRITICAL, format, v...)
+}
+
+func StacktraceError(format string, v ...interface{}) {
+	stacktrace(LEVEL_ERROR, format, v...)
+}
+
+func StacktraceWarn(format string, v ...interface{}) {
+	stacktrace(LEVEL_WARNING, format, v...)
+}
+
+func StacktraceNotice(format string, v ...interface{}) {
+	stacktrace(LEVEL_NOTICE, format, v...)
+}
+
+func StacktraceInfo(format string, v ...interface{}) {
+	stacktrace(LEVEL_INFO, format, v...)
+}
+
+func StacktraceDebug(format string, v ...interface{}) {
+	stacktrace(LEVEL_DEBUG, format, v...)
+}
+
+func StacktraceVerbose(format string, v ...interface{}) {
+	stacktrace(LEVEL_VERBOSE, format, v...)
+}
+
+// stacktrace must be called directly by the exported Stacktrace*
+// functions.
+func stacktrace(level int32, format string, v ...interface{}) {
+	if !std().enabledAt(2, level) {
 		return
 	}
-	_log.output(level, format+" --- stack: \n%s", v, debug.Stack())
+	stack := debug.Stack()
+	if n := atomic.LoadInt32(&stacktraceDepth); n > 0 {
+		// the dump starts with debug.Stack, stacktrace and its caller.
+		stack = trimStack(stack, int(n)+3)
+	}
+	// copy v, appending to it could write to the caller's array.
+	std().outputLines(3, level, format+" --- stack: \n%s", append(v[:len(v):len(v)], stack)...)
+}
+
+var stacktraceDepth int32
+
+// SetStacktraceDepth limits the stack logged by Stacktrace to the top n
+// frames of its caller's stack, followed by the number of frames left
+// out. n <= 0, the
+// default, logs the whole stack.
+func SetStacktraceDepth(n int) {
+	atomic.StoreInt32(&stacktraceDepth, int32(n))
+}
+
+// trimStack cuts a debug.Stack dump after n frames. Each frame is two
+// lines, the function and its file, after the goroutine line.
+func trimStack(stack []byte, n int) []byte {
+	if n <= 0 {
+		return stack
+	}
+	lines := bytes.SplitAfter(bytes.TrimSuffix(stack, []byte("\n")), []byte("\n"))
+	keep := 1 + 2*n
+	if len(lines) <= keep {
+		return stack
+	}
+	more := (len(lines) - keep + 1) / 2
+	trimmed := bytes.Join(lines[:keep], nil)
+	return fmt.Appendf(trimmed, "... %d more frames\n", more)
 }
 
 /*
@@ -214,67 +1149,145 @@ func Stacktrace(level int32, format string, v ...interface{}) {
  * so we add some help functions
  */
 func Debug1(format string, a interface{}) {
-	if LEVEL_DEBUG > GetLevel() {
+	if !std().enabledAt(1, LEVEL_DEBUG) {
 		return
 	}
 
-	_log.output(LEVEL_DEBUG, format, a)
+	std().output(LEVEL_DEBUG, format, a)
 }
 
 func Debug2(format string, a interface{}, b interface{}) {
-	if LEVEL_DEBUG > GetLevel() {
+	if !std().enabledAt(1, LEVEL_DEBUG) {
 		return
 	}
 
-	_log.output(LEVEL_DEBUG, format, a, b)
+	std().output(LEVEL_DEBUG, format, a, b)
 }
 
 func Debug3(format string, a interface{}, b interface{}, c interface{}) {
-	if LEVEL_DEBUG > GetLevel() {
+	if !std().enabledAt(1, LEVEL_DEBUG) {
 		return
 	}
 
-	_log.output(LEVEL_DEBUG, format, a, b, c)
+	std().output(LEVEL_DEBUG, format, a, b, c)
 }
 
 func Debug4(format string, a interface{}, b interface{}, c interface{}, d interface{}) {
-	if LEVEL_DEBUG > GetLevel() {
+	if !std().enabledAt(1, LEVEL_DEBUG) {
 		return
 	}
 
-	_log.output(LEVEL_DEBUG, format, a, b, c, d)
+	std().output(LEVEL_DEBUG, format, a, b, c, d)
 }
 
 func Info1(format string, a interface{}) {
-	if LEVEL_INFO > GetLevel() {
+	if !std().enabledAt(1, LEVEL_INFO) {
 		return
 	}
 
-	_log.output(LEVEL_INFO, format, a)
+	std().output(LEVEL_INFO, format, a)
 }
 
 func Info2(format string, a interface{}, b interface{}) {
-	if LEVEL_INFO > GetLevel() {
+	if !std().enabledAt(1, LEVEL_INFO) {
 		return
 	}
 
-	_log.output(LEVEL_INFO, format, a, b)
+	std().output(LEVEL_INFO, format, a, b)
 }
 
 func Info3(format string, a interface{}, b interface{}, c interface{}) {
-	if LEVEL_INFO > GetLevel() {
+	if !std().enabledAt(1, LEVEL_INFO) {
 		return
 	}
 
-	_log.output(LEVEL_INFO, format, a, b, c)
+	std().output(LEVEL_INFO, format, a, b, c)
 }
 
 func Info4(format string, a interface{}, b interface{}, c interface{}, d interface{}) {
-	if LEVEL_INFO > GetLevel() {
+	if !std().enabledAt(1, LEVEL_INFO) {
+		return
+	}
+
+	std().output(LEVEL_INFO, format, a, b, c, d)
+}
+
+func Warn1(format string, a interface{}) {
+	if !std().enabledAt(1, LEVEL_WARNING) {
+		return
+	}
+
+	std().output(LEVEL_WARNING, format, a)
+}
+
+func Warn2(format string, a interface{}, b interface{}) {
+	if !std().enabledAt(1, LEVEL_WARNING) {
+		return
+	}
+
+	std().output(LEVEL_WARNING, format, a, b)
+}
+
+func Warn3(format string, a interface{}, b interface{}, c interface{}) {
+	if !std().enabledAt(1, LEVEL_WARNING) {
+		return
+	}
+
+	std().output(LEVEL_WARNING, format, a, b, c)
+}
+
+func Warn4(format string, a interface{}, b interface{}, c interface{}, d interface{}) {
+	if !std().enabledAt(1, LEVEL_WARNING) {
 		return
 	}
 
-	_log.output(LEVEL_INFO, format, a, b, c, d)
+	std().output(LEVEL_WARNING, format, a, b, c, d)
+}
+
+// monoStart is the origin of the monotonic readings for Ldelta.
+var monoStart = time.Now()
+
+// delta returns the time since the previous record, 0 for the first one.
+// It is called with the header of each record, in the order records are
+// written; with advance false, for the notes of the logger itself, the
+// call does not count as a record.
+func (l *Logger) delta(advance bool) time.Duration {
+	now := int64(time.Since(monoStart))
+	var prev int64
+	if advance {
+		prev = atomic.SwapInt64(&l.lastRecord, now)
+	} else {
+		prev = atomic.LoadInt64(&l.lastRecord)
+	}
+	if prev == 0 {
+		return 0
+	}
+	return time.Duration(now - prev)
+}
+
+// appendDelta appends d as +12.3ms, with one decimal in the largest unit
+// that fits, or +0s.
+func appendDelta(buf *[]byte, d time.Duration) {
+	*buf = append(*buf, '+')
+	unit, div := "s", time.Second
+	switch {
+	case d <= 0:
+		*buf = append(*buf, "0s"...)
+		return
+	case d < time.Microsecond:
+		itoa(buf, int(d), -1)
+		*buf = append(*buf, "ns"...)
+		return
+	case d < time.Millisecond:
+		unit, div = "µs", time.Microsecond
+	case d < time.Second:
+		unit, div = "ms", time.Millisecond
+	}
+	tenths := int(d / (div / 10))
+	itoa(buf, tenths/10, -1)
+	*buf = append(*buf, '.')
+	itoa(buf, tenths%10, 1)
+	*buf = append(*buf, unit...)
 }
 
 // Cheap integer to fixed-width decimal ASCII.
@@ -298,15 +1311,76 @@ func itoa(buf *[]byte, i int, wid int) {
 	*buf = append(*buf, b[bp:]...)
 }
 
+// lastElems returns the last n elements of path, windows paths may use
+// either separator.
+func lastElems(path string, n int) string {
+	for i := len(path) - 1; i > 0; i-- {
+		if path[i] == '/' || path[i] == '\\' {
+			n--
+			if n == 0 {
+				return path[i+1:]
+			}
+		}
+	}
+	return path
+}
+
 func (l *Logger) formatHeader(buf *[]byte, t time.Time,
-	level int32, file string, line int) {
+	level int32, ci callerInfo) {
+	l.appendHeader(buf, t, level, ci, true)
+}
+
+// formatNote formats the header of a note of the logger itself, like
+// the summary of dropped records; a note does not count as a record for
+// Ldelta.
+func (l *Logger) formatNote(buf *[]byte, t time.Time, level int32) {
+	l.appendHeader(buf, t, level, callerInfo{file: "golog"}, false)
+}
+
+func (l *Logger) appendHeader(buf *[]byte, t time.Time,
+	level int32, ci callerInfo, record bool) {
 
-	//2015-05-14
+	fields := l.headerFields
+	if fields == nil {
+		fields = defaultHeaderFields
+	}
+	for _, f := range fields {
+		switch f {
+		case HeaderTime:
+			l.appendTime(buf, t, record)
+		case HeaderLevel:
+			// [DEBUG] level
+			if l.style != StyleStdlib {
+				*buf = appendLevel(*buf, level)
+				*buf = append(*buf, ' ')
+			}
+		case HeaderCaller:
+			l.appendCaller(buf, ci)
+		}
+	}
+
+	// DEBUG: level for StyleStdlib
+	if l.style == StyleStdlib && l.flags&LstdlibLevelPrefix != 0 {
+		if level >= 0 && int(level) < len(levelNames) {
+			*buf = append(*buf, levelNames[level]...)
+		} else {
+			*buf = strconv.AppendInt(*buf, int64(level), 10)
+		}
+		*buf = append(*buf, ": "...)
+	}
+}
+
+func (l *Logger) appendTime(buf *[]byte, t time.Time, record bool) {
+	//2015-05-14, or 2015/05/14
+	sep := byte('-')
+	if l.style == StyleStdlib {
+		sep = '/'
+	}
 	year, month, day := t.Date()
 	itoa(buf, year, 4)
-	*buf = append(*buf, '-')
+	*buf = append(*buf, sep)
 	itoa(buf, int(month), 2)
-	*buf = append(*buf, '-')
+	*buf = append(*buf, sep)
 	itoa(buf, day, 2)
 	*buf = append(*buf, ' ')
 
@@ -322,56 +1396,410 @@ func (l *Logger) formatHeader(buf *[]byte, t time.Time,
 		itoa(buf, t.Nanosecond()/1e3, 6)
 	}
 	*buf = append(*buf, ' ')
+	if l.flags&Ldelta != 0 {
+		appendDelta(buf, l.delta(record))
+		*buf = append(*buf, ' ')
+	}
+}
 
-	// [DEBUG] level
-	*buf = append(*buf, levelStrings[level]...)
-	*buf = append(*buf, ' ')
-
-	// xxx.go (filename)
-	short := file
-	for i := len(file) - 1; i > 0; i-- {
-		if file[i] == '/' {
-			short = file[i+1:]
-			break
-		}
+func (l *Logger) appendCaller(buf *[]byte, ci callerInfo) {
+	// a cached call site comes rendered as file:line, the line has no
+	// separators to trip lastElems.
+	file := ci.file
+	if ci.pos != "" {
+		file = ci.pos
+	}
+	// xxx.go (filename), or pkg/xxx.go, or the path below pathPrefix
+	if l.pathPrefix != "" && strings.HasPrefix(file, l.pathPrefix) {
+		file = file[len(l.pathPrefix):]
+	} else if l.flags&Lpackagefile != 0 {
+		file = lastElems(file, 2)
+	} else {
+		file = lastElems(file, 1)
 	}
-	file = short
 
 	*buf = append(*buf, file...)
-	*buf = append(*buf, ':')
-	itoa(buf, line, -1)
+	if ci.pos == "" {
+		*buf = append(*buf, ':')
+		itoa(buf, ci.line, -1)
+	}
 	*buf = append(*buf, ": "...)
 }
 
-func (l *Logger) output(level int32, format string, v ...interface{}) error {
-	if level > GetLevel() {
-		return nil
+// SetLineTerminator sets what ends each line: "\n" (the default),
+// "\r\n" for Windows tools, or "\x00" for NUL-delimited transports.
+func SetLineTerminator(terminator string) error {
+	return std().SetLineTerminator(terminator)
+}
+
+func (l *Logger) SetLineTerminator(terminator string) error {
+	switch terminator {
+	case "\n", "\r\n", "\x00":
+	default:
+		return fmt.Errorf("golog: bad line terminator %q", terminator)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.eol = terminator
+	return nil
+}
+
+// SetLineEnding is SetLineTerminator limited to line endings, "\n" or
+// "\r\n".
+func SetLineEnding(le string) error {
+	return std().SetLineEnding(le)
+}
+
+func (l *Logger) SetLineEnding(le string) error {
+	if le != "\n" && le != "\r\n" {
+		return fmt.Errorf("golog: bad line ending %q", le)
+	}
+	return l.SetLineTerminator(le)
+}
+
+// terminate ends the record in buf whose message starts at n with eol
+// ("" meaning "\n"); a line ending the message already has is replaced.
+// With "\r\n" every line of a multi-line message gets it. An empty
+// message is left alone.
+func terminate(buf []byte, n int, eol string) []byte {
+	if len(buf) == n {
+		return buf
+	}
+	if buf[len(buf)-1] == '\n' {
+		buf = buf[:len(buf)-1]
+		if len(buf) > n && buf[len(buf)-1] == '\r' {
+			buf = buf[:len(buf)-1]
+		}
+	}
+	if eol == "" {
+		return append(buf, '\n')
 	}
+	if eol == "\r\n" {
+		buf = crlf(buf, n)
+	}
+	return append(buf, eol...)
+}
 
-	s := fmt.Sprintf(format, v...)
+// crlf turns the bare newlines in buf[n:] into "\r\n".
+func crlf(buf []byte, n int) []byte {
+	bare := 0
+	for i := n; i < len(buf); i++ {
+		if buf[i] == '\n' && (i == n || buf[i-1] != '\r') {
+			bare++
+		}
+	}
+	if bare == 0 {
+		return buf
+	}
 
-	now := time.Now() // get this early.
-	var file string
-	var line int
+	msg := append([]byte(nil), buf[n:]...)
+	buf = buf[:n]
+	for i, c := range msg {
+		if c == '\n' && (i == 0 || msg[i-1] != '\r') {
+			buf = append(buf, '\r')
+		}
+		buf = append(buf, c)
+	}
+	return buf
+}
+
+// SetHeaderFields sets the fields of the global logger's record headers
+// and their order, see Logger.SetHeaderFields.
+func SetHeaderFields(fields HeaderFields) {
+	std().SetHeaderFields(fields)
+}
+
+// SetHeaderFields sets the fields of record headers and their order, to
+// match what a log parser expects; fields left out are not written:
+//
+//	l.SetHeaderFields(golog.HeaderFields{golog.HeaderLevel, golog.HeaderTime})
+//
+// writes "[INFO] 2015-05-14 09:56:00.023132 message". nil restores the
+// default, time, level and caller.
+func (l *Logger) SetHeaderFields(fields HeaderFields) {
+	if fields != nil {
+		fields = append(HeaderFields{}, fields...)
+	}
 	l.mu.Lock()
 	defer l.mu.Unlock()
+	l.headerFields = fields
+}
 
-	// release lock while getting caller info - it's expensive.
-	l.mu.Unlock()
-	var ok bool
-	_, file, line, ok = runtime.Caller(2)
-	if !ok {
-		file = "???"
-		line = 0
+func SetHeaderStyle(style HeaderStyle) {
+	std().SetHeaderStyle(style)
+}
+
+// SetHeaderStyle sets the layout of record headers. StyleStdlib has no
+// level, unless LstdlibLevelPrefix is set (see SetFlags), so tools
+// parsing log package output keep working.
+func (l *Logger) SetHeaderStyle(style HeaderStyle) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.style = style
+}
+
+func SetFlags(flag int) {
+	std().SetFlags(flag)
+}
+
+// SetFlags sets the L* header flags.
+func (l *Logger) SetFlags(flag int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.flags = flag
+}
+
+func (l *Logger) Flags() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.flags
+}
+
+func SetPathPrefix(prefix string) {
+	std().SetPathPrefix(prefix)
+}
+
+// SetPathPrefix strips prefix, typically the module root on the build
+// machine, from caller paths, so they show up relative to the
+// repository. Paths outside prefix are shortened as usual.
+func (l *Logger) SetPathPrefix(prefix string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pathPrefix = prefix
+}
+
+func (l *Logger) output(level int32, format string, v ...interface{}) error {
+	return l.outputDepth(3, level, "", format, v...)
+}
+
+// outputDepth writes one record. calldepth is the number of frames to
+// skip when looking up the caller, counting outputDepth itself as 0.
+// A non-empty prefix is put between the header and the message.
+func (l *Logger) outputDepth(calldepth int, level int32, prefix string,
+	format string, v ...interface{}) error {
+	return l.outputRecord(calldepth+1, level, prefix, nil, 0, format, v...)
+}
+
+// outputLines is outputDepth for records that are multi-line by nature,
+// e.g. stack traces: unless the logger folds newlines, continuation
+// lines start with the marker set by SetContinuationPrefix.
+func (l *Logger) outputLines(calldepth int, level int32, format string, v ...interface{}) error {
+	return l.outputRecord(calldepth+1, level, "", nil, recordLines, format, v...)
+}
+
+// record options for outputRecord.
+const (
+	recordLines    = 1 << iota // see outputLines
+	recordNoCaller             // a record of the logger itself, shown as golog:0 like notes
+)
+
+// outputRecord writes one record; sc are the scope fields of the child
+// it was logged through.
+func (l *Logger) outputRecord(calldepth int, level int32, prefix string, sc []*field, opts int,
+	format string, v ...interface{}) error {
+
+	if l.sampler != nil && (!l.IsLevelEnabled(level) || !l.sampler.pass(level)) {
+		return nil
+	}
+	if l.parent != nil {
+		if l.prefix != "" {
+			prefix = joinPrefix(l.prefix, prefix)
+		}
+		sc = joinScope(l.scope, sc)
+		return l.parent.outputRecord(calldepth+1+l.callerSkip, level, prefix, sc, opts, format, v...)
+	}
+	if l.fanout != nil {
+		return l.broadcast(calldepth+1, level, prefix, sc, opts, format, v...)
+	}
+
+	// with path overrides the level depends on the caller, otherwise
+	// the caller lookup is not worth it for a record we drop.
+	pathLevels := l.pathLevels.Load()
+	if pathLevels == nil && level > atomic.LoadInt32(&l.level) {
+		return nil
+	}
+
+	// get this early; Round(0) drops the monotonic reading, records only
+	// carry wall clock time.
+	now := time.Now().Round(0)
+
+	// get caller info before taking the lock - it's expensive.
+	ci := callerInfo{file: "golog"}
+	if opts&recordNoCaller == 0 {
+		var ok bool
+		if ci, ok = callerSite(calldepth); !ok {
+			ci = callerInfo{file: "???"}
+		}
+	}
+	if pathLevels != nil && level > l.levelFor(*pathLevels, ci.file) {
+		return nil
+	}
+
+	// filters need the message before we take the lock, they may be
+	// slow; without filters there is no extra copy.
+	var msg []byte
+	if filters := l.filters.Load(); filters != nil {
+		msg = fmt.Appendf(nil, format, v...)
+		if ok, counted := runFilters(*filters, level, ci.file, ci.line, string(msg)); !ok {
+			if counted {
+				atomic.AddUint64(&l.filtered, 1)
+			}
+			return nil
+		}
+	}
+
+	if limit := l.limit.Load(); limit != nil {
+		if !limit.acquire() {
+			atomic.AddUint64(&l.limitDropped, 1)
+			l.observe(level, 0, ErrDropped)
+			return ErrDropped
+		}
+		defer limit.release()
+	}
+
+	// the panic value, see EnablePanicOnLevel; the message is formatted
+	// once for it and the record.
+	var panicMsg []byte
+	if l.panicsOn(level) {
+		if msg == nil {
+			msg = fmt.Appendf(nil, format, v...)
+		}
+		panicMsg = msg
 	}
+	sc = joinScope(goroutineScope(), sc)
+
 	l.mu.Lock()
 
+	// header first, then format the message straight into the buffer
+	// so we do not pay for an intermediate string.
 	l.buf = l.buf[:0]
-	l.formatHeader(&l.buf, now, level, file, line)
-	l.buf = append(l.buf, s...)
-	if len(s) > 0 && s[len(s)-1] != '\n' {
-		l.buf = append(l.buf, '\n')
+	l.formatHeader(&l.buf, now, level, ci)
+	n := len(l.buf)
+	if prefix != "" {
+		l.buf = append(l.buf, prefix...)
+		l.buf = append(l.buf, ' ')
+	}
+	if msg != nil {
+		l.buf = append(l.buf, msg...)
+	} else {
+		l.buf = fmt.Appendf(l.buf, format, v...)
+	}
+	l.buf = l.appendContext(l.buf, sc)
+
+	if l.journal != nil {
+		err := l.journal.send(level, ci.file, ci.line, l.buf[n:])
+		l.mu.Unlock()
+		panicAfter(panicMsg, err)
+		return err
+	}
+
+	mode := l.multiline
+	if opts&recordLines != 0 && mode == MultilineKeep {
+		mode = MultilinePrefix
+	}
+	l.buf = foldLines(l.buf, n, mode, l.contPrefix)
+	if l.quote {
+		l.buf = quoteMessage(l.buf, n)
+	}
+	l.buf = terminate(l.buf, n, l.eol)
+	err := l.writeBuf(level, now)
+	panicAfter(panicMsg, err)
+	return err
+}
+
+// writeBuf writes the record in l.buf to the sinks and the output,
+// rotating as needed, and unlocks l.mu, which must be held.
+func (l *Logger) writeBuf(level int32, now time.Time) error {
+	if l.earlyMax > 0 {
+		l.keepEarly(level, now, l.buf)
+	}
+
+	// the writer goroutine writes the sinks too.
+	if l.writeTimeout > 0 && l.sink == nil && !l.atomicWrite {
+		rec := queuedRecord{level, now, append([]byte(nil), l.buf...)}
+		q, timeout := l.queue, l.writeTimeout
+		l.mu.Unlock()
+		start := time.Now()
+		err := l.enqueue(q, rec, timeout)
+		if err == nil {
+			l.counters.written(level, 0)
+		}
+		l.observe(level, time.Since(start), err)
+		l.reportError("write", err)
+		return err
+	}
+
+	sinkErr := l.writeSinks(l.sinks, level, now, l.buf)
+	start := time.Now()
+	err := l.emit(level, now, l.buf)
+	took, writeErr := time.Since(start), err
+	var rotateErr error
+	if err == nil {
+		if note := l.lossNote(now); note != nil {
+			l.emit(LEVEL_CRITICAL, now, note)
+		}
+		rotateErr = l.countLine(now)
+		err = rotateErr
+	}
+	l.mu.Unlock()
+	l.observe(level, took, writeErr)
+	l.reportError("rotate", rotateErr)
+	if err == nil {
+		err = sinkErr
+	}
+	return err
+}
+
+// fallback switches a Logger without an output, like the zero value, to
+// stderr, and writes the notice owed after the file was closed under it.
+// l.mu must be held.
+func (l *Logger) fallback(t time.Time) {
+	if l.out == nil {
+		l.out = os.Stderr
+	}
+	if !l.closed {
+		return
+	}
+	l.closed = false
+	var note []byte
+	l.formatNote(&note, t, LEVEL_WARNING)
+	n := len(note)
+	note = append(note, "log file closed, logging to stderr"...)
+	l.write(l.out, terminate(note, n, l.eol))
+}
+
+// emit writes a finished record to the output, or to the sink that
+// replaced it, and syncs it if asked to. l.mu must be held.
+func (l *Logger) emit(level int32, t time.Time, rec []byte) error {
+	var err error
+	var syncer interface{ Sync() error }
+	if l.sink != nil {
+		err = l.tracked(func() error { return l.sink.Write(level, t, rec) })
+		syncer, _ = l.sink.(interface{ Sync() error })
+	} else if l.atomicWrite && l.file != nil && !l.file.compress {
+		err = l.writeAtomic(rec)
+	} else {
+		if l.out == nil || l.closed {
+			l.fallback(t)
+		}
+		err = l.tracked(func() error {
+			_, err := writeDest(l.out, rec)
+			if errors.Is(err, os.ErrClosed) && l.out != os.Stderr {
+				l.out, l.file = os.Stderr, nil
+				l.closed = true
+				l.fallback(t)
+				_, err = writeDest(l.out, rec)
+			}
+			return err
+		})
+		syncer, _ = l.out.(interface{ Sync() error })
+	}
+	if err == nil && l.syncWrites && syncer != nil {
+		err = syncer.Sync()
+	}
+	if err == nil {
+		l.counters.written(level, len(rec))
 	}
-	_, err := l.out.Write(l.buf)
 	return err
 }
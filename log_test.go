@@ -7,6 +7,18 @@
 package golog
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -52,3 +64,1146 @@ func TestBench(t *testing.T) {
 		Debug("hello %v %v", "abc", "def")
 	}
 }
+
+func newFileLogger(t testing.TB, level int32) (*Logger, string) {
+	path := filepath.Join(t.TempDir(), "golden.log")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return &Logger{out: f, level: level, microseconds: true, shortfile: true}, path
+}
+
+var headerRe = `\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}\.\d{6} `
+
+func TestOutputGolden(t *testing.T) {
+	l, path := newFileLogger(t, LEVEL_INFO)
+
+	l.Info("hello %s %d", "abc", 42)
+	l.Warn("already terminated\n")
+	l.Error("")
+	l.Debug("filtered")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	re := regexp.MustCompile(`^` +
+		headerRe + `\[INFO\] log_test.go:83: hello abc 42\n` +
+		headerRe + `\[WARNING\] log_test.go:84: already terminated\n` +
+		headerRe + `\[ERROR\] log_test.go:85: $`)
+	if !re.Match(data) {
+		t.Fatalf("unexpected output:\n%s", data)
+	}
+}
+
+func BenchmarkOutput(b *testing.B) {
+	f, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer f.Close()
+	l := &Logger{out: f, level: LEVEL_DEBUG, microseconds: true, shortfile: true}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.output(LEVEL_NOTICE, "hello %v %v", "abc", "def")
+	}
+}
+
+func TestDeleteExpiredLog(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	old := time.Now().Add(-2 * time.Hour)
+	for _, name := range []string{"app.log", "app.log.2015051410", "other.log"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"app.log.2015051410", "other.log"} {
+		if err := os.Chtimes(filepath.Join(dir, name), old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	oldFile, oldSaveTime := std().file, std().saveTime
+	defer func() { std().file, std().saveTime = oldFile, oldSaveTime }()
+	std().file = &FileSink{path: path}
+	SetLogSaveTime(time.Hour)
+
+	std().deleteExpiredLog()
+
+	for name, want := range map[string]bool{
+		"app.log":            true,
+		"app.log.2015051410": false,
+		"other.log":          true,
+	} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		if got := err == nil; got != want {
+			t.Errorf("%s exists = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestDiscardLogger(t *testing.T) {
+	l := NewDiscardLogger()
+	allocs := testing.AllocsPerRun(100, func() {
+		l.output(LEVEL_EMERGENCY, "dropped %d", 1)
+	})
+	if allocs != 0 {
+		t.Fatalf("got %v allocs per call, want 0", allocs)
+	}
+}
+
+// captureGlobal points the global logger at a buffer for the test.
+func captureGlobal(t *testing.T, level int32) *bytes.Buffer {
+	buf := &bytes.Buffer{}
+	orig := GetGlobalLogger()
+	SetGlobalLogger(&Logger{out: buf, level: level, microseconds: true, shortfile: true})
+	t.Cleanup(func() { SetGlobalLogger(orig) })
+	return buf
+}
+
+func TestPrintfLogger(t *testing.T) {
+	buf := captureGlobal(t, LEVEL_INFO)
+
+	PrintfLogger().Printf("from %s", "library")
+	PrintfLoggerAt(LEVEL_ERROR).Printf("failed")
+	PrintfLoggerAt(LEVEL_DEBUG).Printf("hidden")
+
+	re := regexp.MustCompile(`^` +
+		headerRe + `\[INFO\] log_test.go:\d+: from library\n` +
+		headerRe + `\[ERROR\] log_test.go:\d+: failed\n$`)
+	if !re.Match(buf.Bytes()) {
+		t.Fatalf("unexpected output:\n%s", buf)
+	}
+}
+
+// Libraries log from their own goroutines, every line must come out
+// whole with the caller of Printf.
+func TestPrintfLoggerConcurrent(t *testing.T) {
+	buf := captureGlobal(t, LEVEL_INFO)
+	p := PrintfLogger()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 10; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 10; i++ {
+				p.Printf("goroutine %d line %d", g, i)
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	re := regexp.MustCompile(`^` + headerRe + `\[INFO\] log_test.go:\d+: goroutine \d line \d$`)
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 100 {
+		t.Fatalf("%d lines, want 100", len(lines))
+	}
+	for _, line := range lines {
+		if !re.MatchString(line) {
+			t.Fatalf("unexpected line %q", line)
+		}
+	}
+}
+
+func TestNextDailyRotateDST(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip(err)
+	}
+	at := 3 * time.Hour
+
+	// clocks go forward on 2026-03-29 and back on 2026-10-25.
+	for _, start := range []time.Time{
+		time.Date(2026, 3, 26, 12, 0, 0, 0, berlin),
+		time.Date(2026, 10, 22, 12, 0, 0, 0, berlin),
+	} {
+		now := start
+		for i := 0; i < 6; i++ {
+			next := nextDailyRotate(now, at, berlin)
+			wall := next.In(berlin)
+			if wall.Hour() != 3 || wall.Minute() != 0 || wall.Second() != 0 {
+				t.Fatalf("rotation after %v at %v, want 03:00 wall clock", now, wall)
+			}
+			if want := start.AddDate(0, 0, i+1).Day(); wall.Day() != want {
+				t.Fatalf("rotation after %v on day %d, want %d", now, wall.Day(), want)
+			}
+			if got, want := dailySuffix(next, berlin), start.AddDate(0, 0, i).Format("20060102"); got != want {
+				t.Fatalf("suffix for %v = %s, want %s", next, got, want)
+			}
+			now = next
+		}
+	}
+
+	// the day clocks go forward is one hour short.
+	d := nextDailyRotate(time.Date(2026, 3, 29, 0, 0, 0, 0, berlin), at, berlin).
+		Sub(time.Date(2026, 3, 28, 3, 0, 0, 0, berlin))
+	if d != 23*time.Hour {
+		t.Fatalf("spring forward day lasted %v", d)
+	}
+}
+
+func TestRotatePeriods(t *testing.T) {
+	for _, period := range []time.Duration{0, -time.Hour, time.Second, 90 * time.Second} {
+		if err := EnableRotate(period); err == nil {
+			t.Errorf("EnableRotate(%v) accepted a bad period", period)
+		}
+	}
+
+	now := time.Date(2026, 10, 15, 13, 47, 12, 0, time.UTC) // a Thursday
+	for _, c := range []struct {
+		period time.Duration
+		start  string
+		suffix string
+	}{
+		{time.Minute, "2026-10-15 13:47:00", "202610151347"},
+		{time.Hour, "2026-10-15 13:00:00", "2026101513"},
+		{6 * time.Hour, "2026-10-15 12:00:00", "20261015120000"},
+		{24 * time.Hour, "2026-10-15 00:00:00", "20261015"},
+		{week, "2026-10-12 00:00:00", "20261012000000"},
+	} {
+		start := periodStart(now, c.period)
+		if got := start.Format("2006-01-02 15:04:05"); got != c.start {
+			t.Errorf("period %v starts at %s, want %s", c.period, got, c.start)
+		}
+		if got := timestr(start, c.period); got != c.suffix {
+			t.Errorf("period %v suffix %s, want %s", c.period, got, c.suffix)
+		}
+	}
+
+	defer SetRotateWeekday(time.Monday)
+	SetRotateWeekday(time.Sunday)
+	if got := periodStart(now, week).Format("2006-01-02 Mon"); got != "2026-10-11 Sun" {
+		t.Errorf("week starting on sunday begins %s", got)
+	}
+}
+
+func TestSetOutput(t *testing.T) {
+	orig := GetGlobalLogger()
+	defer func() { SetGlobalLogger(orig) }()
+	SetGlobalLogger(&Logger{level: LEVEL_INFO})
+	if err := std().openFile(filepath.Join(t.TempDir(), "app.log")); err != nil {
+		t.Fatal(err)
+	}
+	file := std().file
+
+	buf := &bytes.Buffer{}
+	SetOutput(buf)
+	Info("to %s", "buffer")
+
+	if std().FilePath() != "" || !bytes.HasSuffix(buf.Bytes(), []byte(": to buffer\n")) {
+		t.Fatalf("unexpected output %q, path %q", buf, std().FilePath())
+	}
+	if err := file.Write(LEVEL_INFO, time.Now(), []byte("late\n")); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("the replaced file is still open, Write() = %v", err)
+	}
+}
+
+func TestRotateStale(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	orig := GetGlobalLogger()
+	defer func() { SetGlobalLogger(orig) }()
+	SetGlobalLogger(&Logger{out: io.Discard, level: LEVEL_INFO})
+
+	period := time.Hour
+	next := func(now time.Time) time.Time { return periodEnd(now, period) }
+	suffix := func(b time.Time) string { return timestr(b.Add(-period), period) }
+	now := time.Date(2026, 10, 15, 9, 30, 0, 0, time.Local)
+	yesterday := time.Date(2026, 10, 14, 17, 5, 0, 0, time.Local)
+	backup := path + ".2026101417"
+
+	write := func(name, data string, mtime time.Time) {
+		if err := os.WriteFile(name, []byte(data), 0666); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(name, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	read := func(name string) string {
+		data, _ := os.ReadFile(name)
+		return string(data)
+	}
+
+	// no file, nothing to do.
+	if err := std().openFile(path); err != nil {
+		t.Fatal(err)
+	}
+	os.Remove(path)
+	std().rotateStale(now, next, suffix)
+
+	// an empty stale file is reused.
+	write(path, "", yesterday)
+	std().rotateStale(now, next, suffix)
+	if _, err := os.Stat(backup); err == nil {
+		t.Fatal("empty file was rotated")
+	}
+
+	// a file from this period stays.
+	write(path, "current\n", now.Add(-time.Minute))
+	std().rotateStale(now, next, suffix)
+	if read(path) != "current\n" {
+		t.Fatal("current file was rotated")
+	}
+
+	// a stale file gets its historical name, without clobbering an
+	// existing backup.
+	write(backup, "older\n", yesterday)
+	write(path, "stale\n", yesterday)
+	std().rotateStale(now, next, suffix)
+	if read(backup) != "older\n" || read(backup+"-1") != "stale\n" {
+		t.Fatalf("backups: %q %q", read(backup), read(backup+"-1"))
+	}
+	if fi, err := os.Stat(path); err != nil || fi.Size() != 0 {
+		t.Fatalf("log not reopened: %v", err)
+	}
+	std().out.(io.Closer).Close()
+}
+
+func TestSetLineTerminator(t *testing.T) {
+	for _, eol := range []string{"\r\n", "\x00", "\n"} {
+		buf := &bytes.Buffer{}
+		l := &Logger{out: buf, level: LEVEL_INFO}
+		if err := l.SetLineTerminator(eol); err != nil {
+			t.Fatal(err)
+		}
+		l.output(LEVEL_INFO, "one")
+		l.output(LEVEL_INFO, "two\n")
+		l.output(LEVEL_INFO, "")
+
+		lines := strings.SplitAfter(buf.String(), eol)
+		if len(lines) != 3 || !strings.HasSuffix(lines[0], ": one"+eol) ||
+			!strings.HasSuffix(lines[1], ": two"+eol) || !strings.HasSuffix(lines[2], ": ") {
+			t.Errorf("eol %q: unexpected output %q", eol, buf)
+		}
+	}
+
+	if err := SetLineTerminator("\r"); err == nil {
+		t.Error("accepted a bad terminator")
+	}
+}
+
+func TestSetMaxLines(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	l := &Logger{level: LEVEL_INFO}
+	if err := l.openFile(path); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { l.out.(io.Closer).Close() }()
+	l.SetMaxLines(3)
+
+	for i := 0; i < 8; i++ {
+		if err := l.output(LEVEL_INFO, "line %d", i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	names, _ := filepath.Glob(path + ".*")
+	if len(names) != 2 {
+		t.Fatalf("rotated files: %q", names)
+	}
+	sort.Strings(names)
+	counts := []int{}
+	for _, name := range append(names, path) {
+		data, _ := os.ReadFile(name)
+		counts = append(counts, strings.Count(string(data), "\n"))
+	}
+	if fmt.Sprint(counts) != "[3 3 2]" {
+		t.Fatalf("lines per file %v", counts)
+	}
+}
+
+func TestRotateWhileWriting(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	l := &Logger{level: LEVEL_INFO}
+	if err := l.openFile(path); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { l.out.(io.Closer).Close() }()
+
+	const writers, lines = 4, 200
+	done := make(chan bool)
+	for w := 0; w < writers; w++ {
+		go func() {
+			for i := 0; i < lines; i++ {
+				l.output(LEVEL_INFO, "line %d", i)
+			}
+			done <- true
+		}()
+	}
+	for i := 0; i < 5; i++ {
+		if err := l.rotate(fmt.Sprintf("%s.%d", path, i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for w := 0; w < writers; w++ {
+		<-done
+	}
+
+	names, _ := filepath.Glob(path + "*")
+	total := 0
+	for _, name := range names {
+		data, _ := os.ReadFile(name)
+		total += strings.Count(string(data), "\n")
+	}
+	if len(names) != 6 || total != writers*lines {
+		t.Fatalf("%d lines in %q", total, names)
+	}
+}
+
+func TestFormatHeaderFile(t *testing.T) {
+	l := &Logger{}
+	now := time.Now()
+	for file, want := range map[string]string{
+		"/home/ning/golog/log.go":           "log.go",
+		`C:\Users\ning\go\src\golog\log.go`: "log.go",
+		`C:/Users/ning/go/src\golog/log.go`: "log.go",
+		"log.go":                            "log.go",
+		"???":                               "???",
+	} {
+		var buf []byte
+		l.formatHeader(&buf, now, LEVEL_INFO, callerInfo{file: file, line: 7})
+		if got := string(buf); !strings.HasSuffix(got, " [INFO] "+want+":7: ") {
+			t.Errorf("%s: got header %q", file, got)
+		}
+	}
+}
+
+func TestSetLineEnding(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := &Logger{out: buf, level: LEVEL_INFO}
+	if err := l.SetLineEnding("\x00"); err == nil {
+		t.Fatal("accepted NUL as a line ending")
+	}
+	if err := l.SetLineEnding("\r\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	l.output(LEVEL_INFO, "one\n")
+	l.output(LEVEL_INFO, "two\r\n")
+	l.output(LEVEL_INFO, "stack:\nframe 1\r\nframe 2\n")
+
+	out := buf.String()
+	if strings.Count(out, "\n") != strings.Count(out, "\r\n") || strings.Contains(out, "\r\r") {
+		t.Fatalf("bare or doubled line endings in %q", out)
+	}
+	if !strings.Contains(out, ": one\r\n") || !strings.Contains(out, ": two\r\n") ||
+		!strings.HasSuffix(out, ": stack:\r\nframe 1\r\nframe 2\r\n") {
+		t.Fatalf("unexpected output %q", out)
+	}
+}
+
+func TestCallerPathModes(t *testing.T) {
+	const file = "/home/ci/build/src/github.com/acme/app/internal/storage/util.go"
+	now := time.Now()
+	for _, c := range []struct {
+		flags  int
+		prefix string
+		file   string
+		want   string
+	}{
+		{0, "", file, "util.go"},
+		{Lpackagefile, "", file, "storage/util.go"},
+		{Lpackagefile, "", `C:\src\storage\util.go`, `storage\util.go`},
+		{Lpackagefile, "", "util.go", "util.go"},
+		{Lpackagefile, "", "???", "???"},
+		{0, "/home/ci/build/src/github.com/acme/app/", file, "internal/storage/util.go"},
+		{Lpackagefile, "/elsewhere/", file, "storage/util.go"},
+		{0, "/home/ci/", "???", "???"},
+	} {
+		l := &Logger{}
+		l.SetFlags(c.flags)
+		l.SetPathPrefix(c.prefix)
+		var buf []byte
+		l.formatHeader(&buf, now, LEVEL_INFO, callerInfo{file: c.file, line: 17})
+		if got := string(buf); !strings.HasSuffix(got, " "+c.want+":17: ") {
+			t.Errorf("%+v: got header %q", c, got)
+		}
+	}
+
+	l := &Logger{flags: Lpackagefile}
+	buf := make([]byte, 0, 128)
+	allocs := testing.AllocsPerRun(100, func() {
+		buf = buf[:0]
+		l.formatHeader(&buf, now, LEVEL_INFO, callerInfo{file: file, line: 17})
+	})
+	if allocs != 0 {
+		t.Errorf("formatHeader allocates %v times", allocs)
+	}
+}
+
+func TestIsLevelEnabled(t *testing.T) {
+	captureGlobal(t, LEVEL_INFO)
+	if !IsLevelEnabled(LEVEL_ERROR) || !IsErrorEnabled() || !IsWarnEnabled() ||
+		!IsNoticeEnabled() || !IsInfoEnabled() {
+		t.Error("levels up to INFO should be enabled")
+	}
+	if IsLevelEnabled(LEVEL_DEBUG) || IsDebugEnabled() || IsVerboseEnabled() {
+		t.Error("levels below INFO should be disabled")
+	}
+	if NewDiscardLogger().IsLevelEnabled(LEVEL_EMERGENCY) {
+		t.Error("discard logger enables EMERGENCY")
+	}
+}
+
+func TestSetGlobalLogger(t *testing.T) {
+	orig := GetGlobalLogger()
+	defer SetGlobalLogger(orig)
+
+	buf := &bytes.Buffer{}
+	l := &Logger{out: buf, level: LEVEL_DEBUG}
+	SetGlobalLogger(l)
+	if GetGlobalLogger() != l {
+		t.Fatal("GetGlobalLogger did not return the new logger")
+	}
+	Debug("via %s", "package")
+	if !regexp.MustCompile(`\[DEBUG\] log_test.go:\d+: via package\n$`).MatchString(buf.String()) {
+		t.Errorf("got %q", buf.String())
+	}
+}
+
+func TestDefault(t *testing.T) {
+	buf := captureGlobal(t, LEVEL_NOTICE)
+	if Default() != GetGlobalLogger() {
+		t.Fatal("Default is not the global logger")
+	}
+
+	Default().SetLevel(LEVEL_DEBUG)
+	if GetLevel() != LEVEL_DEBUG || Default().GetLevel() != LEVEL_DEBUG {
+		t.Fatalf("level %d after Default().SetLevel", GetLevel())
+	}
+	Debug("shown")
+	if !strings.Contains(buf.String(), "shown") {
+		t.Errorf("got %q", buf.String())
+	}
+}
+
+func TestTrimStack(t *testing.T) {
+	stack := []byte("goroutine 1 [running]:\n" +
+		"main.c()\n\t/src/main.go:30 +0x1d\n" +
+		"main.b()\n\t/src/main.go:20 +0x17\n" +
+		"main.a()\n\t/src/main.go:10 +0x17\n")
+
+	for n, want := range map[int]string{
+		0: string(stack),
+		3: string(stack),
+		5: string(stack),
+		1: "goroutine 1 [running]:\nmain.c()\n\t/src/main.go:30 +0x1d\n... 2 more frames\n",
+		2: "goroutine 1 [running]:\nmain.c()\n\t/src/main.go:30 +0x1d\n" +
+			"main.b()\n\t/src/main.go:20 +0x17\n... 1 more frames\n",
+	} {
+		if got := string(trimStack(stack, n)); got != want {
+			t.Errorf("trimStack(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestSetStacktraceDepth(t *testing.T) {
+	buf := captureGlobal(t, LEVEL_INFO)
+	SetStacktraceDepth(2)
+	defer SetStacktraceDepth(0)
+
+	Stacktrace(LEVEL_ERROR, "oops %v", "x")
+	got := buf.String()
+	if !strings.Contains(got, ": oops x --- stack: \n") ||
+		strings.Count(got, "\n    \t") != 5 || !strings.Contains(got, "TestSetStacktraceDepth") ||
+		!strings.Contains(got, "more frames\n") {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestStacktraceLevels(t *testing.T) {
+	buf := captureGlobal(t, LEVEL_INFO)
+	SetStacktraceDepth(1)
+	defer SetStacktraceDepth(0)
+
+	StacktraceWarn("oops %v", "x")
+	StacktraceDebug("hidden %v", "x")
+	got := buf.String()
+	re := regexp.MustCompile(`^` + headerRe + `\[WARNING\] log_test.go:\d+: oops x --- stack: \n` +
+		`    goroutine \d+ \[running\]:\n(.*\n){4}    \S*StacktraceWarn\(.*\n.*\n    \S*TestStacktraceLevels.*\n.*\n    \.\.\. \d+ more frames\n$`)
+	if !re.MatchString(got) {
+		t.Errorf("got %q", got)
+	}
+	if strings.Contains(got, "hidden") {
+		t.Errorf("logged a disabled level: %q", got)
+	}
+}
+
+func TestRotateDaysInLocation(t *testing.T) {
+	shanghai := time.FixedZone("UTC+8", 8*3600)
+	// 01:30 in UTC+8 is still the previous day in UTC.
+	now := time.Date(2026, 10, 15, 1, 30, 0, 0, shanghai)
+
+	for _, c := range []struct {
+		period     time.Duration
+		start, end string
+	}{
+		{24 * time.Hour, "2026-10-15 00:00 +0800", "2026-10-16 00:00 +0800"},
+		{week, "2026-10-12 00:00 +0800", "2026-10-19 00:00 +0800"},
+		{time.Hour, "2026-10-15 01:00 +0800", "2026-10-15 02:00 +0800"},
+	} {
+		if got := periodStart(now, c.period).Format("2006-01-02 15:04 -0700"); got != c.start {
+			t.Errorf("period %v starts at %s, want %s", c.period, got, c.start)
+		}
+		if got := periodEnd(now, c.period).Format("2006-01-02 15:04 -0700"); got != c.end {
+			t.Errorf("period %v ends at %s, want %s", c.period, got, c.end)
+		}
+	}
+
+	// across a DST change the day is 23 hours long.
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	end := periodEnd(time.Date(2026, 3, 8, 0, 30, 0, 0, ny), 24*time.Hour)
+	if got := end.Format("2006-01-02 15:04 -0700"); got != "2026-03-09 00:00 -0400" {
+		t.Errorf("day ends at %s", got)
+	}
+}
+
+func TestSwapOutputWhileLogging(t *testing.T) {
+	dir := t.TempDir()
+	orig := GetGlobalLogger()
+	defer func() { SetGlobalLogger(orig) }()
+	SetGlobalLogger(&Logger{out: io.Discard, level: LEVEL_INFO})
+
+	names := []string{filepath.Join(dir, "a.log"), filepath.Join(dir, "b.log")}
+	SetFile(names[0])
+	defer Close()
+
+	const writers, lines = 4, 500
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < lines; i++ {
+				Info("line %d", i)
+			}
+		}()
+	}
+	for i := 0; i < 50; i++ {
+		SetFile(names[i%2])
+		ReOpen("")
+	}
+	wg.Wait()
+
+	total := 0
+	for _, name := range names {
+		data, _ := os.ReadFile(name)
+		total += strings.Count(string(data), "\n")
+	}
+	if total != writers*lines {
+		t.Fatalf("%d lines written, want %d", total, writers*lines)
+	}
+}
+
+// run with -race: setters change the configuration while records are
+// written and rotated.
+func TestSettersWhileLogging(t *testing.T) {
+	dir := t.TempDir()
+	orig := GetGlobalLogger()
+	defer func() { SetGlobalLogger(orig) }()
+	SetGlobalLogger(&Logger{out: io.Discard, level: LEVEL_INFO})
+	SetFile(filepath.Join(dir, "app.log"))
+	defer Close()
+	defer SetRotateWeekday(time.Monday)
+	defer SetRotateLocation(nil)
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			Info("line %d", i)
+			std().rotate(filepath.Join(dir, fmt.Sprintf("app.log.%d", i)))
+			std().deleteExpiredLog()
+		}
+	}()
+	for i := 0; i < 50; i++ {
+		SetLevel(LEVEL_INFO)
+		SetFlags(i % 2)
+		SetPathPrefix(dir)
+		SetMaxLines(int64(i))
+		SetLogSaveTime(time.Hour)
+		SetRotateWeekday(time.Weekday(i % 7))
+		SetRotateLocation(time.UTC)
+		SetFile(filepath.Join(dir, "app.log"))
+	}
+	close(done)
+	wg.Wait()
+}
+
+func TestRotateCollision(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	l := &Logger{level: LEVEL_INFO}
+	if err := l.openFile(path); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { l.out.(io.Closer).Close() }()
+
+	backup := path + ".20261015"
+	if err := os.WriteFile(backup, []byte("earlier\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	l.output(LEVEL_INFO, "first")
+	if err := l.rotate(backup); err != nil {
+		t.Fatal(err)
+	}
+	l.output(LEVEL_INFO, "second")
+	if err := l.rotate(backup); err != nil {
+		t.Fatal(err)
+	}
+
+	read := func(name string) string {
+		data, _ := os.ReadFile(name)
+		return string(data)
+	}
+	if read(backup) != "earlier\n" || !strings.HasSuffix(read(backup+"-1"), ": first\n") ||
+		!strings.HasSuffix(read(backup+"-2"), ": second\n") || read(path) != "" {
+		t.Fatalf("backups %q %q %q, current %q",
+			read(backup), read(backup+"-1"), read(backup+"-2"), read(path))
+	}
+}
+
+func TestPrintln(t *testing.T) {
+	buf := captureGlobal(t, LEVEL_INFO)
+
+	Infoln("user", 42, "logged in")
+	Errorln("failed:", errors.New("boom"))
+	Debugln("hidden")
+	Warnln()
+
+	re := regexp.MustCompile(`^` +
+		headerRe + `\[INFO\] log_test.go:\d+: user 42 logged in\n` +
+		headerRe + `\[ERROR\] log_test.go:\d+: failed: boom\n` +
+		headerRe + `\[WARNING\] log_test.go:\d+: \n$`)
+	if !re.MatchString(buf.String()) {
+		t.Errorf("got %q", buf.String())
+	}
+}
+
+func TestWarnN(t *testing.T) {
+	buf := captureGlobal(t, LEVEL_WARNING)
+
+	Warn1("a=%v", 1)
+	Warn2("a=%v b=%v", 1, 2)
+	Warn3("a=%v b=%v c=%v", 1, 2, 3)
+	Warn4("a=%v b=%v c=%v d=%v", 1, 2, 3, 4)
+	Info1("hidden %v", 0)
+
+	re := regexp.MustCompile(`^` +
+		headerRe + `\[WARNING\] log_test.go:\d+: a=1\n` +
+		headerRe + `\[WARNING\] log_test.go:\d+: a=1 b=2\n` +
+		headerRe + `\[WARNING\] log_test.go:\d+: a=1 b=2 c=3\n` +
+		headerRe + `\[WARNING\] log_test.go:\d+: a=1 b=2 c=3 d=4\n$`)
+	if !re.MatchString(buf.String()) {
+		t.Errorf("got %q", buf.String())
+	}
+}
+
+func TestEmergency(t *testing.T) {
+	defer func(old func(int)) { exit = old }(exit)
+	code := -1
+	exit = func(c int) { code = c }
+
+	orig := GetGlobalLogger()
+	defer func() { SetGlobalLogger(orig) }()
+	path := filepath.Join(t.TempDir(), "app.log")
+	SetGlobalLogger(&Logger{out: io.Discard, level: LEVEL_CRITICAL})
+	SetFile(path)
+	SetWritePolicy(DropWithTimeout(time.Second))
+	for i := 0; i < 100; i++ {
+		Critical("line %d", i)
+	}
+
+	Emergency("disk %s is gone", "/data")
+	if code != 2 {
+		t.Errorf("exit code %d, want 2", code)
+	}
+	data, _ := os.ReadFile(path)
+	if n := strings.Count(string(data), "\n"); n != 101 ||
+		!regexp.MustCompile(`\[EMERGENCY\] log_test.go:\d+: disk /data is gone\n$`).Match(data) {
+		t.Errorf("%d lines written, ending in %q", n, data[len(data)-80:])
+	}
+
+	SetEmergencyExitCode(3)
+	defer SetEmergencyExitCode(2)
+	SetOutput(io.Discard)
+	Emergency("again")
+	if code != 3 {
+		t.Errorf("exit code %d, want 3", code)
+	}
+}
+
+func TestLoggerWriter(t *testing.T) {
+	buf := captureGlobal(t, LEVEL_INFO)
+
+	var w io.Writer = Default()
+	fmt.Fprintf(w, "plain %d\n", 1)
+	w.Write([]byte("no newline"))
+	if n, err := w.Write([]byte("two\n\n")); n != 5 || err != nil {
+		t.Errorf("Write returned %d, %v", n, err)
+	}
+
+	re := regexp.MustCompile(`^` +
+		headerRe + `\[INFO\] print.go:\d+: plain 1\n` +
+		headerRe + `\[INFO\] log_test.go:\d+: no newline\n` +
+		headerRe + `\[INFO\] log_test.go:\d+: two\n$`)
+	if !re.MatchString(buf.String()) {
+		t.Errorf("got %q", buf.String())
+	}
+}
+
+func TestLeveledWriter(t *testing.T) {
+	buf := captureGlobal(t, LEVEL_WARNING)
+
+	stdlog := log.New(NewLeveledWriter(LEVEL_ERROR), "http: ", 0)
+	stdlog.Printf("TLS handshake error from %s", "10.0.0.1")
+	NewLeveledWriter(LEVEL_INFO).Write([]byte("hidden\n"))
+
+	re := regexp.MustCompile(`^` +
+		headerRe + `\[ERROR\] log.go:\d+: http: TLS handshake error from 10.0.0.1\n$`)
+	if !re.MatchString(buf.String()) {
+		t.Errorf("got %q", buf.String())
+	}
+}
+
+func TestStdlibHeaderStyle(t *testing.T) {
+	stamp := regexp.MustCompile(`(?m)^\d{4}/\d\d/\d\d \d\d:\d\d:\d\d(\.\d{6})? `)
+	for _, c := range []struct {
+		micro       bool
+		levelPrefix bool
+	}{{true, false}, {false, false}, {true, true}} {
+		var want, got bytes.Buffer
+		flags := log.Ldate | log.Ltime | log.Lshortfile | log.Lmsgprefix
+		if c.micro {
+			flags |= log.Lmicroseconds
+		}
+		stdlog := log.New(&want, "", flags)
+		l := &Logger{out: &got, level: LEVEL_INFO, microseconds: c.micro, style: StyleStdlib}
+		if c.levelPrefix {
+			l.SetFlags(LstdlibLevelPrefix)
+		}
+		// the child reports the caller of logBoth, as stdlog does.
+		child := l.AddCallerSkipFrames(1)
+		logBoth := func(level int32, format string, v ...interface{}) {
+			if c.levelPrefix {
+				stdlog.SetPrefix(levelNames[level] + ": ")
+			}
+			stdlog.Output(2, fmt.Sprintf(format, v...))
+			child.outputDepth(1, level, "", format, v...)
+		}
+
+		logBoth(LEVEL_INFO, "listening on %s", ":8080")
+		logBoth(LEVEL_ERROR, "accept: %v", errors.New("too many open files"))
+
+		w := stamp.ReplaceAllString(want.String(), "TS ")
+		g := stamp.ReplaceAllString(got.String(), "TS ")
+		if g != w || !strings.HasPrefix(g, "TS log_test.go:") {
+			t.Errorf("%+v: got\n%s\nwant\n%s", c, got.String(), want.String())
+		}
+	}
+}
+
+// redirectStderr sends os.Stderr to a file until the test ends and
+// returns a function reading what was written to it.
+func redirectStderr(t *testing.T) func() string {
+	f, err := os.CreateTemp(t.TempDir(), "stderr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	orig := os.Stderr
+	os.Stderr = f
+	t.Cleanup(func() {
+		os.Stderr = orig
+		f.Close()
+	})
+	return func() string {
+		b, err := os.ReadFile(f.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+}
+
+func TestLogAfterClose(t *testing.T) {
+	stderr := redirectStderr(t)
+	path := filepath.Join(t.TempDir(), "app.log")
+	l := &Logger{level: LEVEL_INFO, microseconds: true, shortfile: true}
+	if err := l.openFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	const writers, n = 4, 100
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < n; j++ {
+				l.Info("line %d", j)
+			}
+		}()
+	}
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close() = %v", err)
+	}
+	wg.Wait()
+	l.Info("after close")
+
+	file, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := stderr()
+	if got := strings.Count(string(file), "line ") + strings.Count(out, "line "); got != writers*n {
+		t.Errorf("%d records written, want %d", got, writers*n)
+	}
+	if strings.Count(out, "log file closed") != 1 || !strings.HasSuffix(out, "after close\n") {
+		t.Errorf("stderr:\n%s", out)
+	}
+
+	// a file closed behind the logger's back is detected the same way.
+	f, err := os.Create(filepath.Join(t.TempDir(), "other.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	l = &Logger{out: f, level: LEVEL_INFO}
+	if err := l.output(LEVEL_INFO, "to stderr"); err != nil {
+		t.Errorf("output to a closed file = %v", err)
+	}
+	if out := stderr(); strings.Count(out, "log file closed") != 2 || !strings.HasSuffix(out, "to stderr\n") {
+		t.Errorf("stderr:\n%s", out)
+	}
+}
+
+func TestZeroLogger(t *testing.T) {
+	stderr := redirectStderr(t)
+	var l Logger
+	l.SetLevel(LEVEL_INFO)
+	l.SetFlags(0)
+	l.SetMaxLines(10)
+	l.SetVerbosity(1)
+	l.SetQuoteMessages(false)
+	l.SetBatch(0, 0, 0)
+	l.AddFilter(func(int32, string, string) bool { return true })
+	if err := l.SetLineTerminator("\n"); err != nil {
+		t.Error(err)
+	}
+	if err := l.HealthCheck(); err != nil {
+		t.Errorf("HealthCheck() = %v", err)
+	}
+	if _, err := l.MarshalConfig(); err != nil {
+		t.Errorf("MarshalConfig() = %v", err)
+	}
+	_ = l.Stats()
+	_ = l.Counters()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.Info("zero value")
+		}()
+	}
+	wg.Wait()
+	if err := l.Close(); err != nil {
+		t.Errorf("Close() = %v", err)
+	}
+	l.Info("closed")
+
+	out := stderr()
+	if strings.Count(out, "zero value") != 4 || !strings.HasSuffix(out, "closed\n") || strings.Contains(out, "log file closed") {
+		t.Errorf("stderr:\n%s", out)
+	}
+}
+
+func TestDeltaFlag(t *testing.T) {
+	for _, c := range []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "+0s"},
+		{850, "+850ns"},
+		{12345 * time.Nanosecond, "+12.3µs"},
+		{12345 * time.Microsecond, "+12.3ms"},
+		{1500 * time.Millisecond, "+1.5s"},
+		{time.Hour, "+3600.0s"},
+	} {
+		var buf []byte
+		appendDelta(&buf, c.d)
+		if string(buf) != c.want {
+			t.Errorf("appendDelta(%v) = %q, want %q", c.d, buf, c.want)
+		}
+	}
+
+	var buf bytes.Buffer
+	l := &Logger{out: &buf, level: LEVEL_INFO, microseconds: true, flags: Ldelta}
+	l.Info("first")
+	time.Sleep(20 * time.Millisecond)
+	// a note of the logger does not restart the delta.
+	var note []byte
+	l.formatNote(&note, time.Now(), LEVEL_WARNING)
+	l.Info("second")
+
+	re := regexp.MustCompile(`^` +
+		`\d{4}-\d\d-\d\d \d\d:\d\d:\d\d\.\d{6} \+0s \[INFO\] log_test.go:\d+: first\n` +
+		`\d{4}-\d\d-\d\d \d\d:\d\d:\d\d\.\d{6} \+(\d+)\.\dms \[INFO\] log_test.go:\d+: second\n$`)
+	m := re.FindStringSubmatch(buf.String())
+	if m == nil || len(m[1]) < 2 {
+		t.Errorf("got:\n%s", buf.String())
+	}
+}
+
+func TestOutputTo(t *testing.T) {
+	var out, audit bytes.Buffer
+	l := &Logger{out: &out, level: LEVEL_NOTICE, microseconds: true, shortfile: true}
+
+	l.OutputTo(LEVEL_NOTICE, &audit, "user %s deleted", "bob")
+	l.AddCallerSkipFrames(0).OutputTo(LEVEL_WARNING, &audit, "from child")
+	l.OutputTo(LEVEL_INFO, &audit, "hidden")
+
+	re := regexp.MustCompile(`^` +
+		headerRe + `\[NOTICE\] log_test.go:\d+: user bob deleted\n` +
+		headerRe + `\[WARNING\] log_test.go:\d+: from child\n$`)
+	if !re.MatchString(audit.String()) || out.Len() != 0 {
+		t.Errorf("got %q, output got %q", audit.String(), out.String())
+	}
+}
+
+func TestSetHeaderFields(t *testing.T) {
+	const ts = `\d{4}-\d\d-\d\d \d\d:\d\d:\d\d\.\d{6} `
+	for _, c := range []struct {
+		fields HeaderFields
+		want   string
+	}{
+		{nil, ts + `\[INFO\] log_test.go:\d+: msg\n`},
+		{HeaderFields{HeaderLevel, HeaderTime, HeaderCaller}, `\[INFO\] ` + ts + `log_test.go:\d+: msg\n`},
+		{HeaderFields{HeaderTime, HeaderCaller, HeaderLevel}, ts + `log_test.go:\d+: \[INFO\] msg\n`},
+		{HeaderFields{HeaderLevel}, `\[INFO\] msg\n`},
+		{HeaderFields{}, `msg\n`},
+	} {
+		var buf bytes.Buffer
+		l := &Logger{out: &buf, level: LEVEL_INFO, microseconds: true}
+		l.SetHeaderFields(c.fields)
+		l.Info("msg")
+		if !regexp.MustCompile(`^` + c.want + `$`).MatchString(buf.String()) {
+			t.Errorf("SetHeaderFields(%v): got %q", c.fields, buf.String())
+		}
+	}
+}
+
+func TestSetFileWithPerm(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no unix permissions")
+	}
+	orig := GetGlobalLogger()
+	defer SetGlobalLogger(orig)
+	SetGlobalLogger(&Logger{out: io.Discard, level: LEVEL_INFO})
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	SetFileWithPerm(path, 0600)
+	defer Close()
+	Info("first")
+
+	check := func(what string) {
+		t.Helper()
+		fi, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Mode().Perm() != 0600 {
+			t.Errorf("%s: mode %v, want 0600", what, fi.Mode().Perm())
+		}
+	}
+	check("SetFileWithPerm")
+
+	os.Rename(path, path+".moved")
+	ReOpen(path)
+	check("ReOpen")
+
+	if err := std().rotate(filepath.Join(dir, "app.log.1")); err != nil {
+		t.Fatal(err)
+	}
+	check("rotate")
+}
+
+func TestSetFileMaxAge(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Now().Add(-2 * time.Hour)
+	for _, name := range []string{"a.log.2015051410", "b.log.2015051410"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("old\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	a := &Logger{out: io.Discard, file: &FileSink{path: filepath.Join(dir, "a.log")}}
+	b := &Logger{out: io.Discard, file: &FileSink{path: filepath.Join(dir, "b.log")}}
+	a.SetFileMaxAge(time.Hour)
+	a.deleteExpiredLog()
+	b.deleteExpiredLog()
+
+	if _, err := os.Stat(filepath.Join(dir, "a.log.2015051410")); !os.IsNotExist(err) {
+		t.Errorf("expired file of a kept: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "b.log.2015051410")); err != nil {
+		t.Errorf("file of b, without max age, removed: %v", err)
+	}
+}
+
+func TestIsFileLogger(t *testing.T) {
+	orig := GetGlobalLogger()
+	defer SetGlobalLogger(orig)
+	SetGlobalLogger(&Logger{out: os.Stderr, level: LEVEL_INFO})
+
+	l := GetGlobalLogger()
+	if l.IsFileLogger() || l.FilePath() != "" {
+		t.Errorf("stderr logger: IsFileLogger() = %v, FilePath() = %q", l.IsFileLogger(), l.FilePath())
+	}
+	path := filepath.Join(t.TempDir(), "app.log")
+	SetFile(path)
+	defer Close()
+	child := l.AddCallerSkipFrames(1)
+	if !l.IsFileLogger() || l.FilePath() != path || child.FilePath() != path {
+		t.Errorf("file logger: IsFileLogger() = %v, FilePath() = %q, child %q",
+			l.IsFileLogger(), l.FilePath(), child.FilePath())
+	}
+}
+
+func TestSetBufferSize(t *testing.T) {
+	l := &Logger{level: LEVEL_INFO}
+	l.replaceOut(io.Discard, nil)
+	if cap(l.buf) != defaultBufferSize {
+		t.Errorf("buffer capacity %d after replaceOut", cap(l.buf))
+	}
+
+	l.SetBufferSize(4096)
+	l.Info("%s", strings.Repeat("x", 3000))
+	if cap(l.buf) != 4096 {
+		t.Errorf("buffer capacity %d, want 4096", cap(l.buf))
+	}
+}
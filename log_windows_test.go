@@ -0,0 +1,41 @@
+//go:build windows
+
+package golog
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCallerFileWindows(t *testing.T) {
+	buf := captureGlobal(t, LEVEL_INFO)
+	Info("hello")
+	if !strings.Contains(buf.String(), " log_windows_test.go:") {
+		t.Fatalf("caller not trimmed: %q", buf)
+	}
+}
+
+// windows can not rename an open file, rotate must close it first and
+// keep logging afterwards.
+func TestRotateOpenFileWindows(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	l := &Logger{level: LEVEL_INFO}
+	if err := l.openFile(path); err != nil {
+		t.Fatal(err)
+	}
+	l.output(LEVEL_INFO, "before")
+	if err := l.rotate(path + ".1"); err != nil {
+		t.Fatal(err)
+	}
+	l.output(LEVEL_INFO, "after")
+	l.out.(io.Closer).Close()
+
+	before, _ := os.ReadFile(path + ".1")
+	after, _ := os.ReadFile(path)
+	if !strings.HasSuffix(string(before), ": before\n") || !strings.HasSuffix(string(after), ": after\n") {
+		t.Fatalf("rotated %q, current %q", before, after)
+	}
+}
@@ -0,0 +1,51 @@
+package golog
+
+import "time"
+
+// Metrics receives events from a Logger, to feed a metrics client
+// (StatsD, DataDog, an in-house one) without the logger depending on
+// it. The methods are called without any lock of the logger held, but
+// from every log call: they must be fast and must not log through the
+// same logger.
+type Metrics interface {
+	// IncCounter counts a record written, or queued with
+	// DropWithTimeout, at level.
+	IncCounter(level int32)
+	// ObserveWriteLatency reports how long the write, or the hand-off
+	// to the writer goroutine, of a record at level took.
+	ObserveWriteLatency(level int32, d time.Duration)
+	// IncDrop counts a record at level dropped by the write policy or
+	// by MaxConcurrentWrites.
+	IncDrop(level int32)
+}
+
+func EnableMetrics(m Metrics) {
+	std().EnableMetrics(m)
+}
+
+// EnableMetrics makes l report to m, nil stops it. Counters keeps
+// counting either way.
+func (l *Logger) EnableMetrics(m Metrics) {
+	l = l.root()
+	if m == nil {
+		l.metrics.Store(nil)
+		return
+	}
+	l.metrics.Store(&m)
+}
+
+// observe reports a record at level, written in d or failed with err,
+// to the Metrics of l if any.
+func (l *Logger) observe(level int32, d time.Duration, err error) {
+	m := l.metrics.Load()
+	if m == nil {
+		return
+	}
+	switch err {
+	case nil:
+		(*m).ObserveWriteLatency(level, d)
+		(*m).IncCounter(level)
+	case ErrDropped:
+		(*m).IncDrop(level)
+	}
+}
@@ -0,0 +1,74 @@
+package golog
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testMetrics struct {
+	mu      sync.Mutex
+	counts  map[int32]int
+	drops   map[int32]int
+	latency int
+}
+
+func (m *testMetrics) IncCounter(level int32) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counts[level]++
+}
+
+func (m *testMetrics) ObserveWriteLatency(level int32, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if d >= 0 {
+		m.latency++
+	}
+}
+
+func (m *testMetrics) IncDrop(level int32) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.drops[level]++
+}
+
+func TestEnableMetrics(t *testing.T) {
+	var buf bytes.Buffer
+	l := &Logger{out: &buf, level: LEVEL_INFO}
+	m := &testMetrics{counts: map[int32]int{}, drops: map[int32]int{}}
+	l.AddCallerSkipFrames(0).EnableMetrics(m)
+
+	l.Info("one")
+	l.Error("two")
+	l.Error("three")
+	l.Debug("disabled")
+	if m.counts[LEVEL_INFO] != 1 || m.counts[LEVEL_ERROR] != 2 || len(m.counts) != 2 || m.latency != 3 {
+		t.Errorf("counts %v, %d latencies", m.counts, m.latency)
+	}
+
+	// a record finding no free slot is a drop.
+	w := &stallWriter{entered: make(chan struct{}, 1), release: make(chan struct{})}
+	l.replaceOut(w, nil)
+	l.MaxConcurrentWrites(1)
+	l.SetDropOnFull(true)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		l.Info("stalled")
+	}()
+	<-w.entered
+	l.Warn("dropped")
+	close(w.release)
+	<-done
+	if m.drops[LEVEL_WARNING] != 1 || len(m.drops) != 1 {
+		t.Errorf("drops %v", m.drops)
+	}
+
+	l.EnableMetrics(nil)
+	l.Info("not counted")
+	if m.counts[LEVEL_INFO] != 2 {
+		t.Errorf("counts %v after EnableMetrics(nil)", m.counts)
+	}
+}
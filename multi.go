@@ -0,0 +1,65 @@
+package golog
+
+import "os"
+
+// NewMultiLogger returns a logger writing every record to each of
+// loggers, for a single call site feeding e.g. an audit log and a debug
+// log. Each of loggers applies its own level, filters and format; the
+// level of the multi logger itself is not used, and its IsLevelEnabled
+// is true if any of loggers logs at the level.
+//
+// Setters of the multi logger do not apply to loggers, configure them
+// directly.
+func NewMultiLogger(loggers ...*Logger) *Logger {
+	targets := make([]target, len(loggers))
+	for i, l := range loggers {
+		targets[i] = target{l, -1}
+	}
+	return newFanout(targets)
+}
+
+// TeeLogger returns a logger writing every record to primary, and to
+// secondary at remapLevel, e.g. LEVEL_CRITICAL for an audit log that
+// must get every record whatever its own level; remapLevel -1 keeps the
+// level of the record. See NewMultiLogger.
+func TeeLogger(primary, secondary *Logger, remapLevel int32) *Logger {
+	return newFanout([]target{{primary, -1}, {secondary, remapLevel}})
+}
+
+func newFanout(targets []target) *Logger {
+	return &Logger{out: os.Stderr, level: LEVEL_VERBOSE, fanout: targets}
+}
+
+// A target is a logger a multi logger writes to.
+type target struct {
+	l     *Logger
+	level int32 // records are logged at level, -1 for their own
+}
+
+func (t target) remap(level int32) int32 {
+	if t.level >= 0 {
+		return t.level
+	}
+	return level
+}
+
+// broadcast is outputRecord for a multi logger.
+func (l *Logger) broadcast(calldepth int, level int32, prefix string, sc []*field, opts int,
+	format string, v ...interface{}) error {
+	var err error
+	for _, t := range l.fanout {
+		if terr := t.l.outputRecord(calldepth+1, t.remap(level), prefix, sc, opts, format, v...); err == nil {
+			err = terr
+		}
+	}
+	return err
+}
+
+func (l *Logger) anyEnabled(level int32) bool {
+	for _, t := range l.fanout {
+		if t.l.IsLevelEnabled(t.remap(level)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package golog
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"testing"
+)
+
+func TestMultiLogger(t *testing.T) {
+	var audit, debug bytes.Buffer
+	a := &Logger{out: &audit, level: LEVEL_WARNING, microseconds: true, shortfile: true}
+	d := &Logger{out: &debug, level: LEVEL_DEBUG, microseconds: true, shortfile: true}
+	m := NewMultiLogger(a, d)
+
+	m.Warn("disk %d%% full", 91)
+	m.Debug("cache miss")
+	m.WithError(errors.New("denied")).Error("login")
+	logVia(m.AddCallerSkipFrames(1), "via helper")
+	m.Verbose("hidden")
+
+	if m.IsLevelEnabled(LEVEL_VERBOSE) || !m.IsLevelEnabled(LEVEL_DEBUG) {
+		t.Errorf("IsLevelEnabled does not follow the loggers")
+	}
+	re := regexp.MustCompile(`^` +
+		headerRe + `\[WARNING\] multi_test.go:16: disk 91% full\n` +
+		headerRe + `\[ERROR\] multi_test.go:18: login error="denied" error_type=\*errors.errorString\n$`)
+	if !re.MatchString(audit.String()) {
+		t.Errorf("audit got:\n%s", audit.String())
+	}
+	re = regexp.MustCompile(`^` +
+		headerRe + `\[WARNING\] multi_test.go:16: disk 91% full\n` +
+		headerRe + `\[DEBUG\] multi_test.go:17: cache miss\n` +
+		headerRe + `\[ERROR\] multi_test.go:18: login error="denied" error_type=\*errors.errorString\n` +
+		headerRe + `\[INFO\] multi_test.go:19: via helper\n$`)
+	if !re.MatchString(debug.String()) {
+		t.Errorf("debug got:\n%s", debug.String())
+	}
+}
+
+func TestTeeLogger(t *testing.T) {
+	var app, audit bytes.Buffer
+	primary := &Logger{out: &app, level: LEVEL_INFO, microseconds: true, shortfile: true}
+	secondary := &Logger{out: &audit, level: LEVEL_CRITICAL, microseconds: true, shortfile: true}
+
+	tee := TeeLogger(primary, secondary, LEVEL_CRITICAL)
+	tee.Info("user %s logged in", "bob")
+	tee.Debug("debug, audited anyway")
+	TeeLogger(primary, secondary, -1).Warn("not audited")
+
+	re := regexp.MustCompile(`^` +
+		headerRe + `\[INFO\] multi_test.go:\d+: user bob logged in\n` +
+		headerRe + `\[WARNING\] multi_test.go:\d+: not audited\n$`)
+	if !re.MatchString(app.String()) {
+		t.Errorf("primary got:\n%s", app.String())
+	}
+	re = regexp.MustCompile(`^` +
+		headerRe + `\[CRITICAL\] multi_test.go:\d+: user bob logged in\n` +
+		headerRe + `\[CRITICAL\] multi_test.go:\d+: debug, audited anyway\n$`)
+	if !re.MatchString(audit.String()) {
+		t.Errorf("secondary got:\n%s", audit.String())
+	}
+}
@@ -0,0 +1,107 @@
+package golog
+
+// A MultilineMode says what happens to newlines inside a message.
+type MultilineMode int
+
+const (
+	// MultilineKeep writes the message as is, continuation lines have
+	// no header. This is the default.
+	MultilineKeep MultilineMode = iota
+	// MultilineCollapse replaces each newline with a literal `\n`, so
+	// every record is exactly one line.
+	MultilineCollapse
+	// MultilineIndent starts continuation lines with a tab, so they can
+	// be told apart from records.
+	MultilineIndent
+	// MultilineJoin replaces each newline with a space.
+	MultilineJoin
+	// MultilinePrefix starts continuation lines with the marker set by
+	// SetContinuationPrefix, four spaces by default. Stack traces get
+	// this in MultilineKeep mode too.
+	MultilinePrefix
+)
+
+// default continuation line marker of MultilinePrefix.
+const defaultContPrefix = "    "
+
+func SetMultilineHandling(mode MultilineMode) {
+	std().SetMultilineHandling(mode)
+}
+
+func (l *Logger) SetMultilineHandling(mode MultilineMode) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.multiline = mode
+}
+
+func SetContinuationPrefix(marker string) {
+	std().SetContinuationPrefix(marker)
+}
+
+// SetContinuationPrefix sets the marker continuation lines start with in
+// MultilinePrefix mode, e.g. "| "; "" restores the default of four
+// spaces.
+func (l *Logger) SetContinuationPrefix(marker string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.contPrefix = marker
+}
+
+// foldLines applies mode to the newlines of the message in buf[n:]; a
+// trailing line ending is left for terminate. marker is the
+// MultilinePrefix continuation marker, "" meaning the default.
+func foldLines(buf []byte, n int, mode MultilineMode, marker string) []byte {
+	if mode == MultilineKeep {
+		return buf
+	}
+	end := len(buf)
+	if end > n && buf[end-1] == '\n' {
+		end--
+		if end > n && buf[end-1] == '\r' {
+			end--
+		}
+	}
+	found := false
+	for _, c := range buf[n:end] {
+		if c == '\n' {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return buf
+	}
+
+	msg := append([]byte(nil), buf[n:]...)
+	end -= n
+	buf = buf[:n]
+	for i := 0; i < len(msg); i++ {
+		c := msg[i]
+		if i >= end {
+			buf = append(buf, c)
+			continue
+		}
+		if c == '\r' && i+1 < end && msg[i+1] == '\n' {
+			continue
+		}
+		if c != '\n' {
+			buf = append(buf, c)
+			continue
+		}
+		switch mode {
+		case MultilineCollapse:
+			buf = append(buf, '\\', 'n')
+		case MultilineIndent:
+			buf = append(buf, '\n', '\t')
+		case MultilineJoin:
+			buf = append(buf, ' ')
+		case MultilinePrefix:
+			if marker == "" {
+				marker = defaultContPrefix
+			}
+			buf = append(buf, '\n')
+			buf = append(buf, marker...)
+		}
+	}
+	return buf
+}
@@ -0,0 +1,82 @@
+package golog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestMultilineHandling(t *testing.T) {
+	msg := "SELECT *\nFROM t\r\nWHERE x\n"
+	for mode, want := range map[MultilineMode]string{
+		MultilineKeep:     ": SELECT *\nFROM t\r\nWHERE x\n",
+		MultilineCollapse: `: SELECT *\nFROM t\nWHERE x` + "\n",
+		MultilineIndent:   ": SELECT *\n\tFROM t\n\tWHERE x\n",
+		MultilineJoin:     ": SELECT * FROM t WHERE x\n",
+	} {
+		buf := &bytes.Buffer{}
+		l := &Logger{out: buf, level: LEVEL_INFO}
+		l.SetMultilineHandling(mode)
+		l.output(LEVEL_INFO, "%s", msg)
+		l.output(LEVEL_INFO, "single")
+		if got := buf.String(); !strings.Contains(got, want) || !strings.HasSuffix(got, ": single\n") {
+			t.Errorf("mode %d: got %q, want %q", mode, got, want)
+		}
+	}
+
+	// indented continuation lines get the configured line ending too.
+	buf := &bytes.Buffer{}
+	l := &Logger{out: buf, level: LEVEL_INFO}
+	l.SetMultilineHandling(MultilineIndent)
+	l.SetLineEnding("\r\n")
+	l.output(LEVEL_INFO, "a\nb")
+	if got := buf.String(); !strings.HasSuffix(got, ": a\r\n\tb\r\n") {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestOutputLinesPrefix(t *testing.T) {
+	stack := "goroutine 1 [running]:\n" +
+		"main.c()\n\t/src/main.go:30 +0x1d\n" +
+		"main.b()\n\t/src/main.go:20 +0x17\n" +
+		"main.a()\n\t/src/main.go:10 +0x17\n"
+
+	for _, tt := range []struct {
+		mode   MultilineMode
+		marker string
+		want   string
+	}{
+		{MultilineKeep, "", ": boom\n" +
+			"    goroutine 1 [running]:\n" +
+			"    main.c()\n    \t/src/main.go:30 +0x1d\n" +
+			"    main.b()\n    \t/src/main.go:20 +0x17\n" +
+			"    main.a()\n    \t/src/main.go:10 +0x17\n"},
+		{MultilinePrefix, "| ", ": boom\n" +
+			"| goroutine 1 [running]:\n" +
+			"| main.c()\n| \t/src/main.go:30 +0x1d\n" +
+			"| main.b()\n| \t/src/main.go:20 +0x17\n" +
+			"| main.a()\n| \t/src/main.go:10 +0x17\n"},
+		{MultilineCollapse, "| ", `: boom\ngoroutine 1 [running]:\nmain.c()\n` + "\t" +
+			`/src/main.go:30 +0x1d\nmain.b()\n` + "\t" + `/src/main.go:20 +0x17\nmain.a()\n` + "\t" +
+			`/src/main.go:10 +0x17` + "\n"},
+	} {
+		buf := &bytes.Buffer{}
+		l := &Logger{out: buf, level: LEVEL_INFO}
+		l.SetMultilineHandling(tt.mode)
+		l.SetContinuationPrefix(tt.marker)
+		if err := l.outputLines(0, LEVEL_ERROR, "boom\n%s", stack); err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.String(); !strings.HasSuffix(got, tt.want) || strings.Count(got, "[ERROR]") != 1 {
+			t.Errorf("mode %d: got %q, want suffix %q", tt.mode, got, tt.want)
+		}
+	}
+
+	// plain records keep their newlines as they are.
+	buf := &bytes.Buffer{}
+	l := &Logger{out: buf, level: LEVEL_INFO}
+	l.output(LEVEL_INFO, "a\nb")
+	if got := buf.String(); !strings.HasSuffix(got, ": a\nb\n") {
+		t.Errorf("got %q", got)
+	}
+}
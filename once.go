@@ -0,0 +1,104 @@
+package golog
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+const siteShards = 64
+
+type siteKey struct {
+	file string
+	line int
+}
+
+type siteShard struct {
+	mu sync.RWMutex
+	m  map[siteKey]*uint64
+}
+
+// occurrence counters of the Once and EveryN call sites.
+var sites [siteShards]siteShard
+
+// siteCounter returns the counter of the call site skip frames above its
+// caller, or nil if the caller can't be found.
+func siteCounter(skip int) *uint64 {
+	file, line, ok := caller(skip + 1)
+	if !ok {
+		return nil
+	}
+	key := siteKey{file, line}
+	h := uint32(line)
+	for i := 0; i < len(file); i++ {
+		h = h*31 + uint32(file[i])
+	}
+	shard := &sites[h%siteShards]
+
+	shard.mu.RLock()
+	n := shard.m[key]
+	shard.mu.RUnlock()
+	if n != nil {
+		return n
+	}
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	if n = shard.m[key]; n == nil {
+		if shard.m == nil {
+			shard.m = make(map[siteKey]*uint64)
+		}
+		n = new(uint64)
+		shard.m[key] = n
+	}
+	return n
+}
+
+// ResetCallSites forgets every call site seen by Once and EveryN, so they
+// log again as if seen for the first time. Meant for tests.
+func ResetCallSites() {
+	for i := range sites {
+		sites[i].mu.Lock()
+		sites[i].m = nil
+		sites[i].mu.Unlock()
+	}
+}
+
+// Once logs only the first time it is called from a given line, e.g. for
+// startup warnings in code that runs more than once. Calls made while
+// level is disabled are not counted.
+func Once(level int32, format string, v ...interface{}) {
+	l := std()
+	if !l.enabledAt(1, level) {
+		return
+	}
+	n := siteCounter(1)
+	if n != nil && atomic.AddUint64(n, 1) != 1 {
+		return
+	}
+	l.outputDepth(2, level, "", format, v...)
+}
+
+// EveryN logs the first call from a given line and then every n-th one,
+// followed by the number of calls so far, like "(seen 3000 times)".
+// Calls made while level is disabled are not counted. n <= 1 logs every
+// call.
+func EveryN(n int, level int32, format string, v ...interface{}) {
+	l := std()
+	if !l.enabledAt(1, level) {
+		return
+	}
+	c := siteCounter(1)
+	if c == nil {
+		l.outputDepth(2, level, "", format, v...)
+		return
+	}
+	seen := atomic.AddUint64(c, 1)
+	if seen == 1 {
+		l.outputDepth(2, level, "", format, v...)
+		return
+	}
+	if n > 1 && seen%uint64(n) != 0 {
+		return
+	}
+	l.outputDepth(2, level, "", format+" (seen %d times)", append(v[:len(v):len(v)], seen)...)
+}
@@ -0,0 +1,56 @@
+package golog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOnce(t *testing.T) {
+	buf := captureGlobal(t, LEVEL_INFO)
+	ResetCallSites()
+	t.Cleanup(ResetCallSites)
+
+	for i := 0; i < 3; i++ {
+		Once(LEVEL_WARNING, "deprecated option %d", i)
+		Once(LEVEL_DEBUG, "hidden")
+	}
+	Once(LEVEL_WARNING, "other site")
+
+	got := buf.String()
+	if strings.Count(got, "deprecated option") != 1 || !strings.Contains(got, "deprecated option 0") {
+		t.Errorf("Once logged:\n%s", got)
+	}
+	if !strings.Contains(got, "once_test.go:") || !strings.Contains(got, "other site") {
+		t.Errorf("Once logged:\n%s", got)
+	}
+	if strings.Contains(got, "hidden") {
+		t.Errorf("Once logged a disabled level:\n%s", got)
+	}
+
+	ResetCallSites()
+	buf.Reset()
+	Once(LEVEL_WARNING, "other site")
+	if !strings.Contains(buf.String(), "other site") {
+		t.Errorf("Once after reset logged %q", buf.String())
+	}
+}
+
+func TestEveryN(t *testing.T) {
+	buf := captureGlobal(t, LEVEL_INFO)
+	ResetCallSites()
+	t.Cleanup(ResetCallSites)
+
+	for i := 1; i <= 2500; i++ {
+		EveryN(1000, LEVEL_INFO, "packet %d", i)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	want := []string{"packet 1", "packet 1000 (seen 1000 times)", "packet 2000 (seen 2000 times)"}
+	if len(lines) != len(want) {
+		t.Fatalf("EveryN logged:\n%s", buf.String())
+	}
+	for i, w := range want {
+		if !strings.HasSuffix(lines[i], ": "+w) {
+			t.Errorf("line %d = %q, want suffix %q", i, lines[i], w)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package golog
+
+import "sync/atomic"
+
+func EnablePanicOnLevel(level int32) {
+	std().EnablePanicOnLevel(level)
+}
+
+// EnablePanicOnLevel makes l panic after writing a record at level or
+// more severe, with the message as the panic value. In a test this
+// turns an unexpected Error into a failure with a stack trace:
+//
+//	golog.EnablePanicOnLevel(golog.LEVEL_ERROR)
+//
+// A record that is dropped, not written, does not panic. A level out of
+// range, like LEVEL_EMERGENCY-1, turns it off; the default is off.
+func (l *Logger) EnablePanicOnLevel(level int32) {
+	if level < LEVEL_EMERGENCY || level > LEVEL_VERBOSE {
+		level = LEVEL_EMERGENCY - 1
+	}
+	atomic.StoreInt32(&l.root().panicOn, level+1)
+}
+
+func (l *Logger) panicsOn(level int32) bool {
+	return level < atomic.LoadInt32(&l.panicOn)
+}
+
+// panicAfter panics with msg, if set, unless the record was dropped
+// rather than written, as err tells.
+func panicAfter(msg []byte, err error) {
+	if msg == nil || err == ErrDropped || err == ErrDegraded {
+		return
+	}
+	panic(string(msg))
+}
@@ -0,0 +1,65 @@
+package golog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestEnablePanicOnLevel(t *testing.T) {
+	var buf bytes.Buffer
+	l := &Logger{out: &buf, level: LEVEL_INFO}
+	l.AddCallerSkipFrames(0).EnablePanicOnLevel(LEVEL_ERROR)
+
+	l.Warn("not fatal")
+	func() {
+		defer func() {
+			if r := recover(); r != "disk full: /data" {
+				t.Errorf("recovered %v", r)
+			}
+		}()
+		l.Error("disk full: %s", "/data")
+	}()
+	if got := buf.String(); !strings.Contains(got, "not fatal") || !strings.Contains(got, "disk full: /data\n") {
+		t.Errorf("got:\n%s", got)
+	}
+
+	// the lock was released before the panic.
+	l.EnablePanicOnLevel(LEVEL_ALERT)
+	l.Critical("logged")
+	func() {
+		defer func() {
+			if r := recover(); r != "alert" {
+				t.Errorf("recovered %v at LEVEL_ALERT", r)
+			}
+		}()
+		l.output(LEVEL_ALERT, "alert")
+	}()
+
+	l.EnablePanicOnLevel(LEVEL_EMERGENCY - 1)
+	l.output(LEVEL_ALERT, "alert")
+	l.output(LEVEL_EMERGENCY, "emergency")
+	if !strings.Contains(buf.String(), "logged") || !strings.Contains(buf.String(), "emergency") {
+		t.Errorf("got:\n%s", buf.String())
+	}
+}
+
+func TestPanicOnLevelDropped(t *testing.T) {
+	w := &stallWriter{entered: make(chan struct{}, 1), release: make(chan struct{})}
+	l := &Logger{out: w, level: LEVEL_INFO}
+	l.MaxConcurrentWrites(1)
+	l.SetDropOnFull(true)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		l.Info("stalled")
+	}()
+	<-w.entered
+
+	l.EnablePanicOnLevel(LEVEL_ERROR)
+	if err := l.output(LEVEL_ERROR, "dropped"); err != ErrDropped {
+		t.Errorf("output() = %v, want ErrDropped", err)
+	}
+	close(w.release)
+	<-done
+}
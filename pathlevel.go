@@ -0,0 +1,118 @@
+package golog
+
+import (
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+type pathLevel struct {
+	prefix string
+	sub    string // "/" + prefix, matching below a directory
+	level  int32
+}
+
+func SetLevelForPath(pathPrefix string, level int32) {
+	std().SetLevelForPath(pathPrefix, level)
+}
+
+func RemoveLevelForPath(pathPrefix string) {
+	std().RemoveLevelForPath(pathPrefix)
+}
+
+func LevelsForPath() map[string]int32 {
+	return std().LevelsForPath()
+}
+
+// SetLevelForPath overrides the level for records logged from files under
+// pathPrefix, e.g. "internal/raft/" lets DEBUG through from that
+// directory while the rest stays at the logger's level. pathPrefix is
+// matched against the caller's full path and against the path below
+// every directory of it; the longest matching prefix wins.
+//
+// While overrides are set every log call looks up its caller, even when
+// the logger's level would drop it. IsLevelEnabled and the helpers built
+// on it only look at the logger's level; the Debug1 style shortcuts,
+// Stacktrace, Timed and Once apply the overrides.
+func (l *Logger) SetLevelForPath(pathPrefix string, level int32) {
+	l.updatePathLevels(func(levels []pathLevel) []pathLevel {
+		for i := range levels {
+			if levels[i].prefix == pathPrefix {
+				levels[i].level = level
+				return levels
+			}
+		}
+		return append(levels, pathLevel{pathPrefix, "/" + pathPrefix, level})
+	})
+}
+
+// RemoveLevelForPath removes the override set for pathPrefix.
+func (l *Logger) RemoveLevelForPath(pathPrefix string) {
+	l.updatePathLevels(func(levels []pathLevel) []pathLevel {
+		for i := range levels {
+			if levels[i].prefix == pathPrefix {
+				return append(levels[:i], levels[i+1:]...)
+			}
+		}
+		return levels
+	})
+}
+
+// LevelsForPath returns the overrides set by SetLevelForPath.
+func (l *Logger) LevelsForPath() map[string]int32 {
+	m := make(map[string]int32)
+	if levels := l.pathLevels.Load(); levels != nil {
+		for _, pl := range *levels {
+			m[pl.prefix] = pl.level
+		}
+	}
+	return m
+}
+
+// updatePathLevels replaces the overrides with update applied to a copy
+// of them, longest prefix first.
+func (l *Logger) updatePathLevels(update func([]pathLevel) []pathLevel) {
+	l.filterMu.Lock()
+	defer l.filterMu.Unlock()
+
+	var levels []pathLevel
+	if cur := l.pathLevels.Load(); cur != nil {
+		levels = append(levels, *cur...)
+	}
+	levels = update(levels)
+	if len(levels) == 0 {
+		l.pathLevels.Store(nil)
+		return
+	}
+	sort.Slice(levels, func(i, j int) bool {
+		return len(levels[i].prefix) > len(levels[j].prefix)
+	})
+	l.pathLevels.Store(&levels)
+}
+
+// levelFor returns the level for records from file: the longest matching
+// override, or the logger's level.
+func (l *Logger) levelFor(levels []pathLevel, file string) int32 {
+	for _, pl := range levels {
+		if strings.HasPrefix(file, pl.prefix) || strings.Contains(file, pl.sub) {
+			return pl.level
+		}
+	}
+	return atomic.LoadInt32(&l.level)
+}
+
+// enabledAt tells whether a record at level logged by the function skip
+// frames up from enabledAt's caller passes the level check of
+// outputRecord. The caller is only looked up while overrides are set.
+func (l *Logger) enabledAt(skip int, level int32) bool {
+	l = l.root()
+	pathLevels := l.pathLevels.Load()
+	if pathLevels == nil || l.fanout != nil {
+		return l.IsLevelEnabled(level)
+	}
+	file, _, ok := caller(skip + 1)
+	if !ok {
+		file = "???"
+	}
+	return level <= l.levelFor(*pathLevels, file)
+}
@@ -0,0 +1,91 @@
+package golog
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSetLevelForPath(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Base(wd) + "/"
+	buf := &bytes.Buffer{}
+	l := &Logger{out: buf, level: LEVEL_NOTICE}
+	// one more frame, so the caller is this file, not testing.go.
+	output := func(level int32, msg string) { l.output(level, "%s", msg) }
+
+	l.SetLevelForPath(dir, LEVEL_DEBUG)
+	l.SetLevelForPath(dir+"pathlevel_test", LEVEL_ERROR)
+	l.SetLevelForPath("other/", LEVEL_VERBOSE)
+	output(LEVEL_WARNING, "longest prefix wins")
+	output(LEVEL_ERROR, "error")
+	if got := buf.String(); strings.Contains(got, "longest") || !strings.Contains(got, "error") {
+		t.Errorf("got %q", got)
+	}
+
+	want := map[string]int32{dir: LEVEL_DEBUG, dir + "pathlevel_test": LEVEL_ERROR, "other/": LEVEL_VERBOSE}
+	if got := l.LevelsForPath(); !reflect.DeepEqual(got, want) {
+		t.Errorf("LevelsForPath() = %v, want %v", got, want)
+	}
+
+	buf.Reset()
+	l.RemoveLevelForPath(dir + "pathlevel_test")
+	output(LEVEL_DEBUG, "debug")
+	output(LEVEL_VERBOSE, "verbose")
+	if got := buf.String(); !strings.Contains(got, "debug") || strings.Contains(got, "verbose") {
+		t.Errorf("got %q", got)
+	}
+
+	buf.Reset()
+	l.RemoveLevelForPath(dir)
+	l.RemoveLevelForPath("other/")
+	output(LEVEL_DEBUG, "debug")
+	if buf.Len() != 0 || l.pathLevels.Load() != nil {
+		t.Errorf("overrides left: got %q, %v", buf.String(), l.LevelsForPath())
+	}
+}
+
+func TestLevelForPathShortcuts(t *testing.T) {
+	buf := captureGlobal(t, LEVEL_NOTICE)
+	SetLevelForPath("pathlevel_test.go", LEVEL_DEBUG)
+
+	Debug1("debug1 %d", 1)
+	Info4("info4 %d %d %d %d", 1, 2, 3, 4)
+	Once(LEVEL_DEBUG, "once")
+	Timed(LEVEL_INFO, "timed")()
+	StacktraceDebug("stack")
+	got := buf.String()
+	for _, want := range []string{"debug1 1", "info4 1 2 3 4", "once", "timed took", "stack"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("%q missing in %q", want, got)
+		}
+	}
+}
+
+func benchmarkPathLevel(b *testing.B, prefixes ...string) {
+	f, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer f.Close()
+	l := &Logger{out: f, level: LEVEL_NOTICE, microseconds: true, shortfile: true}
+	for _, prefix := range prefixes {
+		l.SetLevelForPath(prefix, LEVEL_DEBUG)
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.output(LEVEL_INFO, "hello %v %v", "abc", "def")
+	}
+}
+
+// a dropped record with and without overrides; without them there is no
+// caller lookup.
+func BenchmarkDroppedNoPathLevels(b *testing.B) { benchmarkPathLevel(b) }
+func BenchmarkDroppedPathLevel(b *testing.B)    { benchmarkPathLevel(b, "internal/raft/") }
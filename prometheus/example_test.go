@@ -0,0 +1,42 @@
+package prometheus_test
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/idning/golog"
+	"github.com/idning/golog/prometheus"
+	prom "github.com/prometheus/client_golang/prometheus"
+)
+
+func Example() {
+	golog.SetOutput(io.Discard)
+	reg := prom.NewRegistry()
+	if err := prometheus.Register(reg); err != nil {
+		panic(err)
+	}
+	// idempotent
+	if err := prometheus.Register(reg); err != nil {
+		panic(err)
+	}
+
+	golog.Error("disk %s is full", "/data")
+	golog.Error("disk %s is full", "/logs")
+
+	families, err := reg.Gather()
+	if err != nil {
+		panic(err)
+	}
+	for _, mf := range families {
+		if mf.GetName() != "golog_lines_total" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			if level := m.GetLabel()[0].GetValue(); level == "ERROR" {
+				fmt.Printf("%s{level=%q} %v\n", mf.GetName(), level, m.GetCounter().GetValue())
+			}
+		}
+	}
+	// Output:
+	// golog_lines_total{level="ERROR"} 2
+}
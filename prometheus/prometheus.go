@@ -0,0 +1,86 @@
+// Package prometheus exports the counters of golog as Prometheus
+// metrics.
+//
+// It lives in its own package so that golog itself does not depend on
+// the Prometheus client:
+//
+//	if err := prometheus.Register(nil); err != nil {
+//		...
+//	}
+package prometheus
+
+import (
+	"errors"
+
+	"github.com/idning/golog"
+	prom "github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector is a prom.Collector for the counters of a golog.Logger.
+type Collector struct {
+	l *golog.Logger // nil is the global logger at scrape time
+
+	lines     *prom.Desc
+	bytes     *prom.Desc
+	errors    *prom.Desc
+	dropped   *prom.Desc
+	rotations *prom.Desc
+}
+
+// NewCollector returns a Collector for l, or for the global logger,
+// whichever it is when scraped, if l is nil.
+func NewCollector(l *golog.Logger) *Collector {
+	return &Collector{
+		l: l,
+		lines: prom.NewDesc("golog_lines_total",
+			"Log records written, by level.", []string{"level"}, nil),
+		bytes: prom.NewDesc("golog_bytes_written_total",
+			"Bytes written to the log output.", nil, nil),
+		errors: prom.NewDesc("golog_write_errors_total",
+			"Failed writes to the log output.", nil, nil),
+		dropped: prom.NewDesc("golog_dropped_lines_total",
+			"Log records dropped because the output was blocked.", nil, nil),
+		rotations: prom.NewDesc("golog_rotations_total",
+			"Log file rotations.", nil, nil),
+	}
+}
+
+func (c *Collector) Describe(ch chan<- *prom.Desc) {
+	ch <- c.lines
+	ch <- c.bytes
+	ch <- c.errors
+	ch <- c.dropped
+	ch <- c.rotations
+}
+
+func (c *Collector) Collect(ch chan<- prom.Metric) {
+	var cs golog.Counters
+	if c.l != nil {
+		cs = c.l.Counters()
+	} else {
+		cs = golog.GetCounters()
+	}
+	for level, n := range cs.Lines {
+		ch <- prom.MustNewConstMetric(c.lines, prom.CounterValue, float64(n),
+			golog.Level(level).String())
+	}
+	ch <- prom.MustNewConstMetric(c.bytes, prom.CounterValue, float64(cs.BytesWritten))
+	ch <- prom.MustNewConstMetric(c.errors, prom.CounterValue, float64(cs.WriteErrors))
+	ch <- prom.MustNewConstMetric(c.dropped, prom.CounterValue, float64(cs.Dropped))
+	ch <- prom.MustNewConstMetric(c.rotations, prom.CounterValue, float64(cs.Rotations))
+}
+
+// Register registers a Collector for the global logger with reg, or with
+// prom.DefaultRegisterer if reg is nil. Registering again is not an
+// error.
+func Register(reg prom.Registerer) error {
+	if reg == nil {
+		reg = prom.DefaultRegisterer
+	}
+	err := reg.Register(NewCollector(nil))
+	var already prom.AlreadyRegisteredError
+	if errors.As(err, &already) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,94 @@
+package golog
+
+import (
+	"strconv"
+	"unicode/utf8"
+)
+
+func SetQuoteMessages(quote bool) {
+	std().SetQuoteMessages(quote)
+}
+
+// SetQuoteMessages writes every message as a double quoted Go string,
+// escaped like strconv.Quote, so it never contains whitespace a log
+// shipper would split on. The line ending stays outside the quotes. A
+// message that is already quoted is quoted again, so unquoting once
+// always gives back the message as logged.
+func (l *Logger) SetQuoteMessages(quote bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.quote = quote
+}
+
+const lowerhex = "0123456789abcdef"
+
+// quoteMessage quotes the message in buf[n:], less a trailing line
+// ending. The quoted form is built after the message and then moved
+// over it, so no string is made of the message.
+func quoteMessage(buf []byte, n int) []byte {
+	end := len(buf)
+	if end > n && buf[end-1] == '\n' {
+		end--
+		if end > n && buf[end-1] == '\r' {
+			end--
+		}
+	}
+
+	start := len(buf)
+	buf = append(buf, '"')
+	for i := n; i < end; {
+		r, width := utf8.DecodeRune(buf[i:end])
+		if r == utf8.RuneError && width == 1 {
+			buf = append(buf, `\x`...)
+			buf = append(buf, lowerhex[buf[i]>>4], lowerhex[buf[i]&0xF])
+		} else {
+			buf = appendEscapedRune(buf, r)
+		}
+		i += width
+	}
+	buf = append(buf, '"')
+
+	quoted := len(buf) - start
+	copy(buf[n:], buf[start:])
+	return buf[:n+quoted]
+}
+
+// appendEscapedRune appends r the way strconv.Quote writes it.
+func appendEscapedRune(buf []byte, r rune) []byte {
+	switch r {
+	case '"', '\\':
+		return append(buf, '\\', byte(r))
+	case '\a':
+		return append(buf, `\a`...)
+	case '\b':
+		return append(buf, `\b`...)
+	case '\f':
+		return append(buf, `\f`...)
+	case '\n':
+		return append(buf, `\n`...)
+	case '\r':
+		return append(buf, `\r`...)
+	case '\t':
+		return append(buf, `\t`...)
+	case '\v':
+		return append(buf, `\v`...)
+	}
+	if strconv.IsPrint(r) {
+		return utf8.AppendRune(buf, r)
+	}
+	switch {
+	case r < ' ' || r == 0x7f:
+		return append(buf, '\\', 'x', lowerhex[byte(r)>>4], lowerhex[byte(r)&0xF])
+	case r < 0x10000:
+		buf = append(buf, `\u`...)
+		for s := 12; s >= 0; s -= 4 {
+			buf = append(buf, lowerhex[r>>uint(s)&0xF])
+		}
+	default:
+		buf = append(buf, `\U`...)
+		for s := 28; s >= 0; s -= 4 {
+			buf = append(buf, lowerhex[r>>uint(s)&0xF])
+		}
+	}
+	return buf
+}
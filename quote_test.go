@@ -0,0 +1,47 @@
+package golog
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestQuoteMessages(t *testing.T) {
+	for _, tt := range []struct{ msg, want string }{
+		{"plain", `"plain"`},
+		{"", `""`},
+		{`say "hi"`, `"say \"hi\""`},
+		{`"already quoted"`, `"\"already quoted\""`},
+		{`C:\dir\file`, `"C:\\dir\\file"`},
+		{"a\tb=c", `"a\tb=c"`},
+		{"two\nlines", `"two\nlines"`},
+		{"trailing newline\n", `"trailing newline"`},
+		{"bad \xff\xfe utf8", `"bad \xff\xfe utf8"`},
+		{"ctl \x00\x1b\x7f", `"ctl \x00\x1b\x7f"`},
+		{"héllo 世界 \u2028 \U0001F600", `"héllo 世界 \u2028 😀"`},
+	} {
+		buf := &bytes.Buffer{}
+		l := &Logger{out: buf, level: LEVEL_INFO}
+		l.SetQuoteMessages(true)
+		l.output(LEVEL_INFO, "%s", tt.msg)
+
+		got := strings.SplitN(buf.String(), ": ", 2)[1]
+		if got != tt.want+"\n" {
+			t.Errorf("%q logged as %s, want %s", tt.msg, got, tt.want)
+		}
+		if tt.msg != "trailing newline\n" && tt.want != strconv.Quote(tt.msg) {
+			t.Errorf("%q: want %s, strconv.Quote says %s", tt.msg, tt.want, strconv.Quote(tt.msg))
+		}
+	}
+}
+
+func TestQuoteMessagesCRLF(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := &Logger{out: buf, level: LEVEL_INFO, eol: "\r\n"}
+	l.SetQuoteMessages(true)
+	l.output(LEVEL_INFO, "a\nb\r\n")
+	if !bytes.HasSuffix(buf.Bytes(), []byte(`: "a\nb"`+"\r\n")) {
+		t.Errorf("got %q", buf.String())
+	}
+}
@@ -0,0 +1,72 @@
+package golog
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// A RawRecord tells what WriteRecord puts before the lines it is given,
+// see SetRawRecord.
+type RawRecord int
+
+const (
+	RawNone  RawRecord = iota // the line as is
+	RawLevel                  // the level token, e.g. "[INFO] "
+)
+
+func SetRawRecord(r RawRecord) {
+	std().SetRawRecord(r)
+}
+
+// SetRawRecord sets what WriteRecord puts before the lines it is given.
+func (l *Logger) SetRawRecord(r RawRecord) {
+	l = l.root()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.raw = r
+}
+
+func WriteRecord(level int32, t time.Time, line []byte) error {
+	return std().WriteRecord(level, t, line)
+}
+
+// WriteRecord logs line, already formatted elsewhere (e.g. replayed from
+// another system), at level: it is dropped if level is not enabled,
+// otherwise written without a header, only the level token if so set by
+// SetRawRecord, and with a line ending if it has none. Sinks, write
+// policies, counters and rotation apply as for any record; filters and
+// levels by caller path do not, there is no caller.
+func (l *Logger) WriteRecord(level int32, t time.Time, line []byte) error {
+	l = l.root()
+	if l.fanout != nil {
+		var err error
+		for _, c := range l.fanout {
+			if cerr := c.l.WriteRecord(c.remap(level), t, line); err == nil {
+				err = cerr
+			}
+		}
+		return err
+	}
+	if level > atomic.LoadInt32(&l.level) {
+		return nil
+	}
+
+	l.mu.Lock()
+	if l.journal != nil {
+		err := l.journal.send(level, "", 0, line)
+		l.mu.Unlock()
+		return err
+	}
+	l.buf = l.buf[:0]
+	if l.raw == RawLevel {
+		l.buf = appendLevel(l.buf, level)
+		l.buf = append(l.buf, ' ')
+	}
+	l.buf = append(l.buf, line...)
+	l.buf = terminate(l.buf, 0, l.eol)
+	if len(l.buf) == 0 {
+		// terminate leaves an empty message alone, a record is a line.
+		l.buf = terminate(append(l.buf, '\n'), 0, l.eol)
+	}
+	return l.writeBuf(level, t)
+}
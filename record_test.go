@@ -0,0 +1,82 @@
+package golog
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestWriteRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	l := &Logger{level: LEVEL_INFO, microseconds: true, maxLines: 100}
+	if err := l.openFile(path); err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	r, _ := NewRingLogger(10)
+	l.AddSink(r)
+
+	now := time.Now()
+	l.Info("formatted")
+	l.WriteRecord(LEVEL_INFO, now, []byte("replayed one"))
+	l.SetRawRecord(RawLevel)
+	l.WriteRecord(LEVEL_WARNING, now, []byte("replayed two\n"))
+	l.WriteRecord(LEVEL_DEBUG, now, []byte("hidden"))
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	re := regexp.MustCompile(`^` + headerRe + `\[INFO\] record_test.go:\d+: formatted\n` +
+		`replayed one\n` +
+		`\[WARNING\] replayed two\n$`)
+	if !re.Match(data) {
+		t.Errorf("got:\n%s", data)
+	}
+
+	// raw records count like formatted ones.
+	c := l.Counters()
+	if c.Lines[LEVEL_INFO] != 2 || c.Lines[LEVEL_WARNING] != 1 || c.BytesWritten != uint64(len(data)) {
+		t.Errorf("counters %+v for %d bytes", c, len(data))
+	}
+	if l.lines != 3 {
+		t.Errorf("%d lines counted towards rotation, want 3", l.lines)
+	}
+	if e := r.Entries(); len(e) != 3 || e[1].Line != "replayed one\n" || e[2].Time != now {
+		t.Errorf("sink got %+v", e)
+	}
+}
+
+func TestWriteRecordUnknownLevel(t *testing.T) {
+	var buf bytes.Buffer
+	l := &Logger{out: &buf, level: LEVEL_VERBOSE + 2, raw: RawLevel}
+	l.WriteRecord(-1, time.Now(), []byte("negative"))
+	l.WriteRecord(LEVEL_VERBOSE+1, time.Now(), []byte("past verbose"))
+	if got, want := buf.String(), "[-1] negative\n[9] past verbose\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestWriteRecordEmpty(t *testing.T) {
+	for _, c := range []struct {
+		raw  RawRecord
+		eol  string
+		want string
+	}{
+		{RawNone, "", "\n\n"},
+		{RawNone, "\r\n", "\r\n\r\n"},
+		{RawNone, "\x00", "\x00\x00"},
+		{RawLevel, "", "[INFO] \n[INFO] \n"},
+	} {
+		var buf bytes.Buffer
+		l := &Logger{out: &buf, level: LEVEL_INFO, raw: c.raw, eol: c.eol}
+		l.WriteRecord(LEVEL_INFO, time.Now(), nil)
+		l.WriteRecord(LEVEL_INFO, time.Now(), []byte("\n"))
+		if buf.String() != c.want {
+			t.Errorf("%+v: got %q", c, buf.String())
+		}
+	}
+}
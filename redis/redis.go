@@ -0,0 +1,150 @@
+// Package redis publishes golog lines to a Redis pub/sub channel.
+//
+//	w, err := redis.NewRedisWriter("localhost:6379", "app-logs")
+//	if err != nil {
+//		...
+//	}
+//	golog.SetOutput(w)
+//
+// Delivery is fire-and-forget: lines logged while Redis is unreachable
+// are lost, and the writer reconnects on a later Write.
+package redis
+
+import (
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type config struct {
+	timeout time.Duration
+}
+
+// A RedisOption changes the defaults of NewRedisWriter.
+type RedisOption func(*config)
+
+// WithTimeout bounds how long a Write may block, connecting included.
+// The default is 100ms.
+func WithTimeout(d time.Duration) RedisOption {
+	return func(c *config) {
+		c.timeout = d
+	}
+}
+
+var errClosed = errors.New("golog/redis: writer closed")
+
+type writer struct {
+	addr    string
+	channel string
+	timeout time.Duration
+
+	mu       sync.Mutex // protects the following fields
+	conn     net.Conn
+	nextDial time.Time // do not hammer a server that is down
+	buf      []byte
+	closed   bool
+}
+
+// NewRedisWriter returns a writer that PUBLISHes every log line to
+// channel on the Redis server at addr. The first connection is made
+// eagerly so a wrong address is reported here.
+func NewRedisWriter(addr, channel string, opts ...RedisOption) (io.WriteCloser, error) {
+	if channel == "" {
+		return nil, errors.New("golog/redis: no channel")
+	}
+	c := config{timeout: 100 * time.Millisecond}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	w := &writer{addr: addr, channel: channel, timeout: c.timeout}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.connect(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// connect dials the server; w.mu must be held.
+func (w *writer) connect() error {
+	conn, err := net.DialTimeout("tcp", w.addr, w.timeout)
+	if err != nil {
+		w.nextDial = time.Now().Add(w.timeout)
+		return err
+	}
+	w.conn = conn
+	// PUBLISH replies with the number of receivers, which we ignore; a
+	// read error means the connection is gone.
+	go func() {
+		io.Copy(io.Discard, conn)
+		w.mu.Lock()
+		if w.conn == conn {
+			w.conn = nil
+		}
+		w.mu.Unlock()
+		conn.Close()
+	}()
+	return nil
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, errClosed
+	}
+	if w.conn == nil {
+		if time.Now().Before(w.nextDial) {
+			return 0, errors.New("golog/redis: not connected")
+		}
+		if err := w.connect(); err != nil {
+			return 0, err
+		}
+	}
+
+	w.buf = appendPublish(w.buf[:0], w.channel, p)
+	w.conn.SetWriteDeadline(time.Now().Add(w.timeout))
+	if _, err := w.conn.Write(w.buf); err != nil {
+		w.conn.Close()
+		w.conn = nil
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w *writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.closed = true
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+// appendPublish encodes PUBLISH channel msg in RESP, without the
+// trailing newline of msg.
+func appendPublish(buf []byte, channel string, msg []byte) []byte {
+	if n := len(msg); n > 0 && msg[n-1] == '\n' {
+		msg = msg[:n-1]
+	}
+	buf = append(buf, "*3\r\n$7\r\nPUBLISH\r\n"...)
+	buf = appendBulk(buf, []byte(channel))
+	return appendBulk(buf, msg)
+}
+
+func appendBulk(buf []byte, b []byte) []byte {
+	buf = append(buf, '$')
+	buf = strconv.AppendInt(buf, int64(len(b)), 10)
+	buf = append(buf, "\r\n"...)
+	buf = append(buf, b...)
+	return append(buf, "\r\n"...)
+}
@@ -0,0 +1,139 @@
+package redis
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeRedis answers PUBLISH commands and sends their messages on msgs.
+type fakeRedis struct {
+	ln    net.Listener
+	msgs  chan string
+	conns chan net.Conn
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeRedis{ln: ln, msgs: make(chan string, 100), conns: make(chan net.Conn, 10)}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			s.conns <- conn
+			go s.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { ln.Close() })
+	return s
+}
+
+func (s *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		if len(args) == 3 && args[0] == "PUBLISH" {
+			s.msgs <- args[1] + " " + args[2]
+		}
+		io.WriteString(conn, ":1\r\n")
+	}
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	var n int
+	if _, err := fmt.Fscanf(r, "*%d\r\n", &n); err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		var size int
+		if _, err := fmt.Fscanf(r, "$%d\r\n", &size); err != nil {
+			return nil, err
+		}
+		b := make([]byte, size+2)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, err
+		}
+		args[i] = string(b[:size])
+	}
+	return args, nil
+}
+
+func (s *fakeRedis) next(t *testing.T) string {
+	select {
+	case m := <-s.msgs:
+		return m
+	case <-time.After(time.Second):
+		t.Fatal("no message published")
+		return ""
+	}
+}
+
+func TestRedisWriter(t *testing.T) {
+	s := newFakeRedis(t)
+	w, err := NewRedisWriter(s.ln.Addr().String(), "logs", WithTimeout(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	if _, err := w.Write([]byte("first line\n")); err != nil {
+		t.Fatal(err)
+	}
+	if m := s.next(t); m != "logs first line" {
+		t.Fatalf("got %q", m)
+	}
+
+	// the server drops the connection, the writer reconnects.
+	(<-s.conns).Close()
+	deadline := time.Now().Add(time.Second)
+	for i := 0; ; i++ {
+		w.Write([]byte("line " + strconv.Itoa(i) + "\n"))
+		select {
+		case m := <-s.msgs:
+			if !strings.HasPrefix(m, "logs line ") {
+				t.Fatalf("got %q", m)
+			}
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("writer did not reconnect")
+		}
+	}
+}
+
+func TestRedisWriterUnreachable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	if _, err := NewRedisWriter(addr, "logs"); err == nil {
+		t.Fatal("connected to a closed port")
+	}
+}
+
+func TestAppendPublish(t *testing.T) {
+	got := string(appendPublish(nil, "ch", []byte("héllo\n")))
+	want := "*3\r\n$7\r\nPUBLISH\r\n$2\r\nch\r\n$6\r\nhéllo\r\n"
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,96 @@
+package golog
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// An Entry is a record kept by a RingLogger.
+type Entry struct {
+	Time  time.Time
+	Level Level
+	Line  string // the formatted record, header and line ending included
+}
+
+// A RingLogger keeps the last records logged in memory, e.g. for a crash
+// reporter: once it is full, every record replaces the oldest one. It is
+// a Sink, so it can be added to another logger with AddSink.
+type RingLogger struct {
+	mu      sync.Mutex
+	entries []Entry
+	next    int // where the next entry goes
+	full    bool
+}
+
+// NewRingLogger returns a RingLogger keeping capacity records and a
+// Logger, at LEVEL_VERBOSE, writing to it.
+func NewRingLogger(capacity int) (*RingLogger, *Logger) {
+	if capacity < 1 {
+		capacity = 1
+	}
+	r := &RingLogger{entries: make([]Entry, capacity)}
+	l := &Logger{
+		out:          io.Discard,
+		sink:         r,
+		level:        LEVEL_VERBOSE,
+		microseconds: true,
+		shortfile:    true,
+	}
+	return r, l
+}
+
+func (r *RingLogger) Write(level int32, t time.Time, line []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries[r.next] = Entry{t, Level(level), string(line)}
+	r.next++
+	if r.next == len(r.entries) {
+		r.next = 0
+		r.full = true
+	}
+	return nil
+}
+
+func (r *RingLogger) Reopen() error { return nil }
+func (r *RingLogger) Close() error  { return nil }
+
+// Entries returns a copy of the records kept, oldest first.
+func (r *RingLogger) Entries() []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.full {
+		return append([]Entry(nil), r.entries[:r.next]...)
+	}
+	entries := make([]Entry, 0, len(r.entries))
+	entries = append(entries, r.entries[r.next:]...)
+	return append(entries, r.entries[:r.next]...)
+}
+
+// appendTo appends the records kept to buf, oldest first, for a crash
+// dump. It gives up if r is locked.
+func (r *RingLogger) appendTo(buf []byte) []byte {
+	if !r.mu.TryLock() {
+		return buf
+	}
+	defer r.mu.Unlock()
+	if r.full {
+		for _, e := range r.entries[r.next:] {
+			buf = append(buf, e.Line...)
+		}
+	}
+	for _, e := range r.entries[:r.next] {
+		buf = append(buf, e.Line...)
+	}
+	return buf
+}
+
+// Dump writes the records kept to w, oldest first.
+func (r *RingLogger) Dump(w io.Writer) error {
+	for _, e := range r.Entries() {
+		if _, err := io.WriteString(w, e.Line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
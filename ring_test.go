@@ -0,0 +1,52 @@
+package golog
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRingLogger(t *testing.T) {
+	r, l := NewRingLogger(3)
+	if len(r.Entries()) != 0 {
+		t.Fatal("new ring is not empty")
+	}
+	l.output(LEVEL_INFO, "line 0")
+	if e := r.Entries(); len(e) != 1 || e[0].Level != LEVEL_INFO || e[0].Time.IsZero() ||
+		!strings.HasSuffix(e[0].Line, ": line 0\n") {
+		t.Fatalf("entries %v", e)
+	}
+
+	for i := 1; i < 5; i++ {
+		l.output(LEVEL_DEBUG, "line %d", i)
+	}
+	entries := r.Entries()
+	if len(entries) != 3 {
+		t.Fatalf("%d entries, want 3", len(entries))
+	}
+	for i, e := range entries {
+		if want := fmt.Sprintf(": line %d\n", i+2); !strings.HasSuffix(e.Line, want) {
+			t.Errorf("entry %d is %q, want suffix %q", i, e.Line, want)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := r.Dump(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(buf.String(), "\n") != 3 || !strings.HasSuffix(buf.String(), ": line 4\n") {
+		t.Errorf("Dump wrote %q", buf.String())
+	}
+}
+
+func TestRingLoggerAsSink(t *testing.T) {
+	buf := captureGlobal(t, LEVEL_INFO)
+	r, _ := NewRingLogger(10)
+	AddSink(r)
+
+	Info("kept in both")
+	if e := r.Entries(); len(e) != 1 || e[0].Line != buf.String() {
+		t.Errorf("ring has %v, output %q", e, buf.String())
+	}
+}
@@ -0,0 +1,152 @@
+package golog
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// clock is the time source of rotation, replaced in tests.
+type clock interface {
+	Now() time.Time
+	AfterFunc(d time.Duration, f func()) stopper
+}
+
+type stopper interface {
+	Stop() bool
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) AfterFunc(d time.Duration, f func()) stopper {
+	return time.AfterFunc(d, f)
+}
+
+var rotateClock clock = realClock{}
+
+// rotator renames the file of a Logger at every boundary returned by
+// next, naming the old file with suffix(boundary). Its schedule can be
+// changed while it runs, see SetRotatePeriod.
+type rotator struct {
+	l      *Logger
+	clock  clock
+	report func(op string, err error) // rotation failures, the owner's noteError
+
+	mu     sync.Mutex // serializes retarget and rotations, taken before l.rotateMu
+	period time.Duration
+	next   func(now time.Time) time.Time
+	suffix func(boundary time.Time) string
+	timer  stopper
+	gen    uint64    // bumped by retarget, timers set before do nothing
+	start  time.Time // when the current file was started
+}
+
+// retarget switches r over to a new schedule, starting it on the first
+// call. If the current file already spans a boundary of the new
+// schedule it is rotated right away, so no file covers more than one
+// period.
+func (r *rotator) retarget(period time.Duration, next func(now time.Time) time.Time,
+	suffix func(boundary time.Time) string) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.retargetLocked(period, next, suffix)
+}
+
+// retargetLocked is retarget with r.mu held.
+func (r *rotator) retargetLocked(period time.Duration, next func(now time.Time) time.Time,
+	suffix func(boundary time.Time) string) {
+
+	now := r.clock.Now()
+	if r.timer == nil {
+		r.report("rotate", r.l.rotateStale(now, next, suffix))
+		r.start = now
+	} else {
+		r.timer.Stop()
+		if b := next(r.start); !b.After(now) {
+			for nb := next(b); !nb.After(now); nb = next(nb) {
+				b = nb
+			}
+			r.suffix = suffix
+			r.rotate(b)
+			r.start = now
+		}
+	}
+	r.period, r.next, r.suffix = period, next, suffix
+	r.gen++
+
+	r.l.mu.Lock()
+	r.l.rotatePeriod = period
+	r.l.mu.Unlock()
+
+	r.schedule()
+}
+
+// stop cancels the timer, a timer firing meanwhile does nothing. A
+// retarget starts r again.
+func (r *rotator) stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.timer != nil {
+		r.timer.Stop()
+		r.timer = nil
+	}
+	r.gen++
+}
+
+// schedule sets the timer for the next boundary, r.mu must be held. The
+// timer fires a second late so a clock running slightly behind never
+// sees the old period.
+func (r *rotator) schedule() {
+	now := r.clock.Now()
+	boundary := r.next(now)
+	gen := r.gen
+	r.timer = r.clock.AfterFunc(boundary.Sub(now)+time.Second, func() {
+		r.fire(gen, boundary)
+	})
+}
+
+func (r *rotator) fire(gen uint64, boundary time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if gen != r.gen {
+		// retargeted while the timer was firing.
+		return
+	}
+	r.rotate(boundary)
+	r.start = boundary
+	r.schedule()
+}
+
+// rotate renames the file to suffix(boundary), r.mu must be held.
+func (r *rotator) rotate(boundary time.Time) {
+	l := r.l
+	path := l.filePath()
+	err := l.rotate(fmt.Sprintf("%s.%s", path, r.suffix(boundary)))
+	if err != nil {
+		err = fmt.Errorf("rotate %s: %w", path, err)
+	}
+	r.report("rotate", err)
+	go l.deleteExpiredLog()
+}
+
+// SetRotatePeriod changes the period of the rotation started by
+// EnableRotate or EnableRotateDaily, e.g. to per minute files while
+// debugging an incident. The current file is rotated right away if it
+// already spans a boundary of the new period; after that files are named
+// as EnableRotate(period) would name them.
+func SetRotatePeriod(period time.Duration) error {
+	return std().setRotatePeriod(period)
+}
+
+func (l *Logger) setRotatePeriod(period time.Duration) error {
+	l.mu.RLock()
+	r := l.rotator
+	l.mu.RUnlock()
+	if r == nil {
+		return fmt.Errorf("golog: rotation is not enabled")
+	}
+	return l.enableRotate(period)
+}
@@ -0,0 +1,160 @@
+package golog
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock fires its timers when advanced.
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	at time.Time
+	f  func()
+}
+
+// Stop does not remove the timer, as if it had already fired: the
+// rotator must ignore it.
+func (*fakeTimer) Stop() bool { return false }
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) AfterFunc(d time.Duration, f func()) stopper {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTimer{c.now.Add(d), f}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+// advance moves the clock to t, firing the timers due in order.
+func (c *fakeClock) advance(t time.Time) {
+	for {
+		c.mu.Lock()
+		i := -1
+		for j, tm := range c.timers {
+			if !tm.at.After(t) && (i < 0 || tm.at.Before(c.timers[i].at)) {
+				i = j
+			}
+		}
+		if i < 0 {
+			c.now = t
+			c.mu.Unlock()
+			return
+		}
+		tm := c.timers[i]
+		c.timers = append(c.timers[:i], c.timers[i+1:]...)
+		c.now = tm.at
+		c.mu.Unlock()
+		tm.f()
+	}
+}
+
+func TestSetRotatePeriod(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	l := &Logger{level: LEVEL_INFO}
+	if err := l.openFile(path); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { l.out.(io.Closer).Close() }()
+
+	at := func(h, m, s int) time.Time { return time.Date(2015, 5, 14, h, m, s, 0, time.UTC) }
+	c := &fakeClock{now: at(10, 30, 0)}
+	defer func(old clock) { rotateClock = old }(rotateClock)
+	rotateClock = c
+	SetRotateLocation(time.UTC)
+	defer SetRotateLocation(nil)
+
+	if err := l.setRotatePeriod(time.Minute); err == nil {
+		t.Fatal("SetRotatePeriod without rotation succeeded")
+	}
+	if err := l.enableRotate(time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	c.advance(at(11, 20, 0))
+
+	// the file started at 11:00 and spans minute boundaries: rotated
+	// right away.
+	if err := l.setRotatePeriod(time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	// the hourly timer at 12:00 is stale.
+	c.advance(at(12, 0, 30))
+
+	if err := l.setRotatePeriod(time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	c.advance(at(13, 0, 30))
+
+	want := []string{"app.log.2015051410"}
+	for m := 19; m < 60; m++ {
+		want = append(want, fmt.Sprintf("app.log.2015051411%02d", m))
+	}
+	want = append(want, "app.log.2015051412")
+
+	entries, _ := os.ReadDir(dir)
+	var got []string
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), "app.log.") {
+			got = append(got, e.Name())
+		}
+	}
+	sort.Strings(got)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("rotated to\n%v\nwant\n%v", got, want)
+	}
+	if cfg, _ := l.MarshalConfig(); cfg.RotatePeriod != "1h0m0s" {
+		t.Errorf("config has rotate period %q", cfg.RotatePeriod)
+	}
+}
+
+func TestRotatorReportsToOwner(t *testing.T) {
+	global := captureGlobal(t, LEVEL_VERBOSE)
+	dir := filepath.Join(t.TempDir(), "logs")
+	os.Mkdir(dir, 0777)
+	l := &Logger{level: LEVEL_INFO}
+	if err := l.openFile(filepath.Join(dir, "audit.log")); err != nil {
+		t.Fatal(err)
+	}
+	defer l.file.Close()
+
+	at := func(h, m, s int) time.Time { return time.Date(2015, 5, 14, h, m, s, 0, time.UTC) }
+	c := &fakeClock{now: at(10, 30, 0)}
+	defer func(old clock) { rotateClock = old }(rotateClock)
+	rotateClock = c
+	SetRotateLocation(time.UTC)
+	defer SetRotateLocation(nil)
+	if err := l.enableRotate(time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	// the rename fails, root ignores permissions.
+	os.RemoveAll(dir)
+	c.advance(at(11, 0, 30))
+	l.health.mu.Lock()
+	err := l.health.rotateErr
+	l.health.mu.Unlock()
+	if err == nil || !strings.Contains(err.Error(), "rotate "+filepath.Join(dir, "audit.log")) {
+		t.Errorf("rotation error %v", err)
+	}
+	if global.Len() != 0 {
+		t.Errorf("the global logger got %q", global.String())
+	}
+}
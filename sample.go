@@ -0,0 +1,52 @@
+package golog
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// NewSampledLogger returns a child of l passing on a rate fraction,
+// between 0 and 1, of the records logged through it: with rate 0.01 the
+// first record and then every 100th one at each level. Levels are
+// counted apart, so frequent debug records do not thin out errors. The
+// counting is an atomic add, there is no lock or random source.
+//
+// Records are counted once their level is enabled. Children of the
+// sampled logger share its counts. Sampling a sampled logger applies
+// both rates, the records passed by the new one are sampled by l. The
+// prefix and scope of l are kept.
+func NewSampledLogger(l *Logger, rate float64) *Logger {
+	s := &sampler{next: l.sampler}
+	switch {
+	case rate >= 1:
+		s.every = 1
+	case rate > 0:
+		s.every = uint64(math.Round(1 / rate))
+	}
+	return &Logger{parent: l.root(), callerSkip: l.callerSkip, sampler: s, prefix: l.prefix, scope: l.scope}
+}
+
+type sampler struct {
+	every  uint64 // pass one record out of every, none if 0
+	counts [LEVEL_VERBOSE + 1]uint64
+	next   *sampler // of the logger sampled, applied to the records passed
+}
+
+func (s *sampler) pass(level int32) bool {
+	if s.every == 0 {
+		return false
+	}
+	if level >= 0 && int(level) < len(s.counts) &&
+		(atomic.AddUint64(&s.counts[level], 1)-1)%s.every != 0 {
+		return false
+	}
+	return s.next == nil || s.next.pass(level)
+}
+
+// clone returns a sampler with the rates of s and no counts.
+func (s *sampler) clone() *sampler {
+	if s == nil {
+		return nil
+	}
+	return &sampler{every: s.every, next: s.next.clone()}
+}
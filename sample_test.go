@@ -0,0 +1,72 @@
+package golog
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSampledLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := &Logger{out: &buf, level: LEVEL_INFO, shortfile: true}
+
+	s := NewSampledLogger(l, 0.25)
+	for i := 0; i < 8; i++ {
+		s.Info("info %d", i)
+		s.Error("error %d", i)
+		s.Debug("disabled %d", i)
+	}
+	got := buf.String()
+	for _, want := range []string{"info 0\n", "info 4\n", "error 0\n", "error 4\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("%q not logged", want)
+		}
+	}
+	if n := strings.Count(got, "\n"); n != 4 || !strings.Contains(got, "sample_test.go:") {
+		t.Errorf("got:\n%s", got)
+	}
+
+	// concurrent calls pass exactly the rate, children share the counts.
+	buf.Reset()
+	s = NewSampledLogger(l, 0.1)
+	child := s.AddCallerSkipFrames(0)
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				s.Info("line")
+				child.Info("line")
+			}
+		}()
+	}
+	wg.Wait()
+	if n := strings.Count(buf.String(), "\n"); n != 40 {
+		t.Errorf("%d of 400 records logged at rate 0.1", n)
+	}
+
+	buf.Reset()
+	NewSampledLogger(l, 0).Error("dropped")
+	NewSampledLogger(l, 1).Info("kept")
+	if got := buf.String(); strings.Contains(got, "dropped") || !strings.Contains(got, "kept") {
+		t.Errorf("got:\n%s", got)
+	}
+}
+
+func TestSampledLoggerNested(t *testing.T) {
+	var buf bytes.Buffer
+	l := &Logger{out: &buf, level: LEVEL_INFO}
+	db := &Logger{parent: l, prefix: "db"}
+
+	s := NewSampledLogger(NewSampledLogger(db, 0.5), 0.5)
+	for i := 0; i < 8; i++ {
+		s.Info("line %d", i)
+	}
+	got := buf.String()
+	if n := strings.Count(got, "\n"); n != 2 || !strings.Contains(got, ": db line 0\n") ||
+		!strings.Contains(got, ": db line 4\n") {
+		t.Errorf("got:\n%s", got)
+	}
+}
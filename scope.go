@@ -0,0 +1,168 @@
+package golog
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// scopeShards holds the fields pushed by PushScope per goroutine id.
+var (
+	scopeShards [64]struct {
+		mu sync.Mutex
+		m  map[uint64][]*field
+	}
+	scopeCount atomic.Int64 // fields pushed and not released, in all goroutines
+)
+
+// A ScopeToken identifies a scope pushed by PushScope.
+type ScopeToken struct {
+	id uint64 // goroutine
+	f  *field
+}
+
+// PushScope adds key=value to every record logged by the calling
+// goroutine, to any logger, until the scope is released, e.g. to
+// correlate the records of a request in code that has no logger or
+// context to pass around:
+//
+//	defer golog.PushScope("req", id).Release()
+//
+// Scopes nest, the fields of the outer ones come first, and fields of a
+// child logger, see WithScope, come after them. The scope must be
+// released, on the same goroutine or not, or the fields are kept after
+// the goroutine exits. While no scope is pushed records do not pay for
+// scopes; while any is, every record looks up its goroutine.
+func PushScope(key string, value interface{}) ScopeToken {
+	id := goid()
+	f := &field{key, value}
+	s := &scopeShards[id%uint64(len(scopeShards))]
+	s.mu.Lock()
+	if s.m == nil {
+		s.m = make(map[uint64][]*field)
+	}
+	s.m[id] = append(s.m[id], f)
+	s.mu.Unlock()
+	scopeCount.Add(1)
+	return ScopeToken{id, f}
+}
+
+// PopScope releases the scope of t. Releasing a scope again, or the zero
+// ScopeToken, does nothing.
+func PopScope(t ScopeToken) {
+	if t.f != nil {
+		removeScope(t.id, t.f)
+	}
+}
+
+// Release is PopScope(t).
+func (t ScopeToken) Release() {
+	PopScope(t)
+}
+
+func removeScope(id uint64, f *field) {
+	s := &scopeShards[id%uint64(len(scopeShards))]
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fields := s.m[id]
+	for i, g := range fields {
+		if g == f {
+			// copy, goroutineScope hands out the old slice.
+			fields = append(fields[:i:i], fields[i+1:]...)
+			scopeCount.Add(-1)
+			break
+		}
+	}
+	if len(fields) == 0 {
+		delete(s.m, id)
+	} else {
+		s.m[id] = fields
+	}
+}
+
+// goroutineScope returns the fields pushed by the calling goroutine, nil
+// without a goroutine id lookup if no goroutine pushed any.
+func goroutineScope() []*field {
+	if scopeCount.Load() == 0 {
+		return nil
+	}
+	id := goid()
+	s := &scopeShards[id%uint64(len(scopeShards))]
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.m[id]
+}
+
+// goid returns the id of the calling goroutine, parsed from the
+// "goroutine 18 [running]:" line that starts its stack trace.
+func goid() uint64 {
+	var buf [64]byte
+	b := bytes.TrimPrefix(buf[:runtime.Stack(buf[:], false)], []byte("goroutine "))
+	var id uint64
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			break
+		}
+		id = id*10 + uint64(c-'0')
+	}
+	return id
+}
+
+func WithScope(key string, value interface{}) *Logger {
+	return std().WithScope(key, value)
+}
+
+// WithScope returns a child of l that adds key=value to every record it
+// logs, e.g. to correlate the records of a request:
+//
+//	rl := l.WithScope("req", id)
+//	rl.Info("start") // ... start req=42
+//
+// Scopes nest, the fields of the outer ones come first. A field takes
+// precedence over those of outer scopes with the same key, including
+// those pushed by PushScope, and over default fields. The fields live on
+// the child, so records of other loggers do not pay for them.
+func (l *Logger) WithScope(key string, value interface{}) *Logger {
+	return l.withFields(&field{key, value})
+}
+
+// withFields returns a child of l with fields added to its scope.
+func (l *Logger) withFields(fields ...*field) *Logger {
+	return &Logger{
+		parent:     l.root(),
+		callerSkip: l.callerSkip,
+		sampler:    l.sampler,
+		prefix:     l.prefix,
+		scope:      append(l.scope[:len(l.scope):len(l.scope)], fields...),
+	}
+}
+
+// joinScope puts the scope fields of a child before those of the record.
+func joinScope(outer, inner []*field) []*field {
+	if len(inner) == 0 {
+		return outer
+	}
+	return append(outer[:len(outer):len(outer)], inner...)
+}
+
+// appendScope appends the scope fields, except those overridden by a
+// later field with the same key.
+func appendScope(buf []byte, fields []*field) []byte {
+	for i, f := range fields {
+		if !scopeHas(fields[i+1:], f.key) {
+			buf = fmt.Appendf(buf, " %s=%v", f.key, f.value)
+		}
+	}
+	return buf
+}
+
+func scopeHas(fields []*field, key string) bool {
+	for _, f := range fields {
+		if f.key == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,143 @@
+package golog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestWithScope(t *testing.T) {
+	buf := captureGlobal(t, LEVEL_INFO)
+	SetDefaultFields(map[string]interface{}{"svc": "api", "user": "nobody"})
+
+	outer := WithScope("req", 7)
+	outer.Info("outer")
+	inner := outer.WithScope("user", "bob")
+	inner.Info("inner")
+	inner.WithError(errors.New("denied")).With("user", "eve").Warn("entry")
+	outer.Info("outer again")
+	Info("none")
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	want := []string{
+		": outer req=7 svc=api user=nobody",
+		": inner req=7 user=bob svc=api",
+		`: entry req=7 error="denied" error_type=*errors.errorString user=eve svc=api`,
+		": outer again req=7 svc=api user=nobody",
+		": none svc=api user=nobody",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got:\n%s", buf.String())
+	}
+	for i, w := range want {
+		if !strings.HasSuffix(lines[i], w) {
+			t.Errorf("line %d = %q, want suffix %q", i, lines[i], w)
+		}
+	}
+}
+
+func TestWithScopeConcurrent(t *testing.T) {
+	var buf bytes.Buffer
+	l := &Logger{out: &buf, level: LEVEL_INFO}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 100; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			rl := l.WithScope("req", g)
+			for i := 0; i < 10; i++ {
+				rl.Info("goroutine %d", g)
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 1000 {
+		t.Fatalf("%d lines", len(lines))
+	}
+	for _, line := range lines {
+		var g int
+		_, msg, _ := strings.Cut(line, ": goroutine ")
+		if _, err := fmt.Sscanf(msg, "%d", &g); err != nil ||
+			!strings.HasSuffix(line, fmt.Sprintf("goroutine %d req=%d", g, g)) {
+			t.Fatalf("wrong scope: %q", line)
+		}
+	}
+}
+
+func TestPushScope(t *testing.T) {
+	buf := captureGlobal(t, LEVEL_INFO)
+	SetDefaultFields(map[string]interface{}{"svc": "api", "user": "nobody"})
+
+	outer := PushScope("req", 7)
+	Info("outer")
+	inner := PushScope("user", "bob")
+	Info("inner")
+	WithScope("user", "eve").Warn("child")
+	PopScope(inner)
+	PopScope(inner)
+	Info("after inner")
+	PopScope(PushScope("step", 2))
+	outer.Release()
+	PopScope(ScopeToken{})
+	Info("none")
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	want := []string{
+		": outer req=7 svc=api user=nobody",
+		": inner req=7 user=bob svc=api",
+		": child req=7 user=eve svc=api",
+		": after inner req=7 svc=api user=nobody",
+		": none svc=api user=nobody",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got:\n%s", buf.String())
+	}
+	for i, w := range want {
+		if !strings.HasSuffix(lines[i], w) {
+			t.Errorf("line %d = %q, want suffix %q", i, lines[i], w)
+		}
+	}
+	if n := scopeCount.Load(); n != 0 {
+		t.Errorf("%d scope fields left", n)
+	}
+}
+
+func TestPushScopeConcurrent(t *testing.T) {
+	var buf bytes.Buffer
+	l := &Logger{out: &buf, level: LEVEL_INFO}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 100; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			defer PushScope("req", g).Release()
+			for i := 0; i < 10; i++ {
+				l.Info("goroutine %d", g)
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 1000 {
+		t.Fatalf("%d lines", len(lines))
+	}
+	for _, line := range lines {
+		var g int
+		_, msg, _ := strings.Cut(line, ": goroutine ")
+		if _, err := fmt.Sscanf(msg, "%d", &g); err != nil ||
+			!strings.HasSuffix(line, fmt.Sprintf("goroutine %d req=%d", g, g)) {
+			t.Fatalf("wrong scope: %q", line)
+		}
+	}
+	if n := scopeCount.Load(); n != 0 {
+		t.Errorf("%d scope fields left", n)
+	}
+}
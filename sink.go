@@ -0,0 +1,298 @@
+package golog
+
+import (
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// A Sink is an output backend, e.g. a file, a network service or a
+// cloud logging API. Write gets every record with its level and time;
+// line is the formatted record, line ending included, and is only valid
+// during the call. Reopen is called by ReOpen, e.g. after logrotate
+// moved a file away, and Close by Close. A Sink that also has a
+// Sync() error method is synced when records must be durable, see
+// Audit.
+//
+// Sinks are called with the logger's lock held, one record at a time.
+type Sink interface {
+	Write(level int32, t time.Time, line []byte) error
+	Reopen() error
+	Close() error
+}
+
+func SetSink(s Sink) {
+	std().SetSink(s)
+}
+
+// SetSink sends records to s instead of the file or writer set by
+// SetFile or SetOutput, which in turn replace s. The file, journal or
+// sink replaced is closed. Rotation only applies to SetFile files; sinks
+// handle their own. With DropWithTimeout, records for s are still
+// written synchronously.
+func (l *Logger) SetSink(s Sink) {
+	l.mu.Lock()
+	old := l.detach()
+	l.sink = s
+	l.out = io.Discard
+	l.lines = 0
+	l.closed = false
+	l.replayEarly()
+	l.mu.Unlock()
+
+	l.retire(old)
+}
+
+func AddSink(s Sink) {
+	std().AddSink(s)
+}
+
+// AddSink sends every record to s as well, whatever the output is. Write
+// errors of s are returned by the log call, but do not put the logger in
+// degraded mode. With DropWithTimeout s is written by the writer
+// goroutine, see there.
+func (l *Logger) AddSink(s Sink) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sinks = append(l.sinks[:len(l.sinks):len(l.sinks)], s)
+}
+
+// writeSinks writes a record to sinks, those added by AddSink, and
+// returns the first error. Log calls write them under l.mu, the writer
+// goroutine of DropWithTimeout without it; sinkMu keeps the two apart.
+func (l *Logger) writeSinks(sinks []Sink, level int32, t time.Time, rec []byte) error {
+	if len(sinks) == 0 {
+		return nil
+	}
+	l.sinkMu.Lock()
+	defer l.sinkMu.Unlock()
+	var err error
+	for _, s := range sinks {
+		if serr := s.Write(level, t, rec); err == nil {
+			err = serr
+		}
+	}
+	return err
+}
+
+// reopenSinks reopens the sink set by SetSink and those added by
+// AddSink. l.mu must be held.
+func (l *Logger) reopenSinks() error {
+	var err error
+	for _, s := range l.allSinks() {
+		if serr := s.Reopen(); err == nil {
+			err = serr
+		}
+	}
+	return err
+}
+
+// closeSinks is reopenSinks for Close.
+func (l *Logger) closeSinks() error {
+	var err error
+	for _, s := range l.allSinks() {
+		if serr := s.Close(); err == nil {
+			err = serr
+		}
+	}
+	return err
+}
+
+func (l *Logger) allSinks() []Sink {
+	if l.sink == nil {
+		return l.sinks
+	}
+	return append([]Sink{l.sink}, l.sinks...)
+}
+
+// FileSink is a Sink appending to a file. It is also how a Logger
+// writes the file set by SetFile and the like, which reopens, rotates
+// and closes it through the sink.
+type FileSink struct {
+	mu       sync.Mutex // held by writes and while the file is swapped
+	path     string
+	compress bool        // a gzip stream, see SetFileCompressed
+	perm     os.FileMode // reopened and rotated files are created with perm
+	w        io.Writer   // *os.File, *gzipFile if compress
+	closed   bool
+}
+
+// NewFileSink opens path for appending.
+func NewFileSink(path string) (*FileSink, error) {
+	return openFileSink(path, false, defaultPerm)
+}
+
+// openFileSink is NewFileSink for a compressed file or another perm, see
+// openFile.
+func openFileSink(path string, compress bool, perm os.FileMode) (*FileSink, error) {
+	if perm == 0 {
+		perm = defaultPerm
+	}
+	w, err := openFile(path, compress, perm)
+	if err != nil {
+		return nil, err
+	}
+	return &FileSink{path: path, compress: compress, perm: perm, w: w}, nil
+}
+
+func (s *FileSink) Write(level int32, t time.Time, line []byte) error {
+	_, err := s.write(line)
+	return err
+}
+
+func (s *FileSink) write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
+
+// Sync flushes the file to disk; a compressed file only gets there on
+// its next flush.
+func (s *FileSink) Sync() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if f, ok := s.w.(*os.File); ok {
+		return f.Sync()
+	}
+	return nil
+}
+
+// Reopen opens path again, e.g. after logrotate moved the file away,
+// and closes the old file. If that fails the old file is kept. Writes
+// only wait for the swap.
+func (s *FileSink) Reopen() error {
+	w, err := openFile(s.path, s.compress, s.perm)
+	if err != nil {
+		return err
+	}
+	return s.swap(w)
+}
+
+// swap makes w the file written to and closes the old one. If the sink
+// was closed meanwhile w is closed instead.
+func (s *FileSink) swap(w io.Writer) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return closeWriter(w)
+	}
+	old := s.w
+	s.w = w
+	s.mu.Unlock()
+	return closeWriter(old)
+}
+
+// rotate renames the file to filename and opens a new one at path.
+// Writes go on to the old file, now called filename, until the new one
+// is swapped in, except where an open file can not be renamed: there
+// they wait for the whole rotation. If the new file can not be opened
+// the old one is closed all the same, writes fail until Reopen
+// succeeds.
+func (s *FileSink) rotate(filename string) (renameErr, openErr error) {
+	if renameOpenFiles {
+		s.mu.Lock()
+		closed := s.closed
+		s.mu.Unlock()
+		if closed {
+			return nil, nil
+		}
+		renameErr = os.Rename(s.path, filename)
+		w, err := openFile(s.path, s.compress, s.perm)
+		if err != nil {
+			s.mu.Lock()
+			closeWriter(s.w)
+			s.mu.Unlock()
+			return renameErr, err
+		}
+		s.swap(w)
+		return renameErr, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, nil
+	}
+	closeWriter(s.w)
+	renameErr = os.Rename(s.path, filename)
+	w, err := openFile(s.path, s.compress, s.perm)
+	if err != nil {
+		return renameErr, err
+	}
+	s.w = w
+	return renameErr, nil
+}
+
+// Close closes the file, ending a compressed stream. Writes fail
+// afterwards.
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return os.ErrClosed
+	}
+	s.closed = true
+	return closeWriter(s.w)
+}
+
+// Path returns the file name the sink writes to, "" for a nil sink.
+func (s *FileSink) Path() string {
+	if s == nil {
+		return ""
+	}
+	return s.path
+}
+
+// file returns the open file, for HealthCheck.
+func (s *FileSink) file() *os.File {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch w := s.w.(type) {
+	case *os.File:
+		return w
+	case *gzipFile:
+		return w.f
+	}
+	return nil
+}
+
+func closeWriter(w io.Writer) error {
+	if c, ok := w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// fileWriter is the output of a Logger writing to a FileSink.
+type fileWriter struct {
+	s *FileSink
+}
+
+func (w fileWriter) Write(p []byte) (int, error) { return w.s.write(p) }
+func (w fileWriter) Sync() error                 { return w.s.Sync() }
+func (w fileWriter) Close() error                { return w.s.Close() }
+
+// closeFunc makes a close method an io.Closer, see detach.
+type closeFunc func() error
+
+func (f closeFunc) Close() error { return f() }
+
+// detach clears the file, journal and sink l writes to, and returns
+// them to be closed with retire once the output is replaced. A writer
+// given to SetOutput belongs to the caller and is left open. l.mu must
+// be held.
+func (l *Logger) detach() []io.Closer {
+	var old []io.Closer
+	if l.file != nil {
+		old = append(old, l.file)
+	}
+	if l.journal != nil {
+		old = append(old, closeFunc(l.journal.close))
+	}
+	if l.sink != nil {
+		old = append(old, l.sink)
+	}
+	l.file, l.journal, l.sink = nil, nil, nil
+	return old
+}
@@ -0,0 +1,104 @@
+package golog
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type recordSink struct {
+	levels  []int32
+	times   []time.Time
+	lines   []string
+	reopens int
+	closed  bool
+	err     error
+}
+
+func (s *recordSink) Write(level int32, t time.Time, line []byte) error {
+	s.levels = append(s.levels, level)
+	s.times = append(s.times, t)
+	s.lines = append(s.lines, string(line))
+	return s.err
+}
+
+func (s *recordSink) Reopen() error { s.reopens++; return nil }
+func (s *recordSink) Close() error  { s.closed = true; return nil }
+
+func TestSetSink(t *testing.T) {
+	buf := captureGlobal(t, LEVEL_INFO)
+	primary, extra := &recordSink{}, &recordSink{}
+
+	SetSink(primary)
+	AddSink(extra)
+	Info("to %s", "sinks")
+	Error("again")
+
+	if buf.Len() != 0 {
+		t.Errorf("output still written: %q", buf.String())
+	}
+	for _, s := range []*recordSink{primary, extra} {
+		if len(s.lines) != 2 || !strings.HasSuffix(s.lines[0], ": to sinks\n") ||
+			s.levels[0] != LEVEL_INFO || s.levels[1] != LEVEL_ERROR || s.times[0].IsZero() {
+			t.Errorf("sink got %q %v", s.lines, s.levels)
+		}
+	}
+
+	ReOpen("")
+	if primary.reopens != 1 || extra.reopens != 1 {
+		t.Errorf("reopened %d and %d times", primary.reopens, extra.reopens)
+	}
+
+	// SetOutput replaces the sink, added sinks stay.
+	SetOutput(buf)
+	Info("back")
+	if !strings.HasSuffix(buf.String(), ": back\n") || len(primary.lines) != 2 || len(extra.lines) != 3 {
+		t.Errorf("after SetOutput: %q, %d, %d", buf.String(), len(primary.lines), len(extra.lines))
+	}
+
+	extra.err = errors.New("unreachable")
+	if err := std().output(LEVEL_INFO, "x"); err != extra.err {
+		t.Errorf("output returned %v, want the sink's error", err)
+	}
+
+	Close()
+	if !extra.closed {
+		t.Error("added sink not closed")
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	s, err := NewFileSink(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := &Logger{out: &bytes.Buffer{}, level: LEVEL_INFO}
+	l.SetSink(s)
+
+	l.output(LEVEL_INFO, "before")
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	l.output(LEVEL_INFO, "after")
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	old, _ := os.ReadFile(path + ".1")
+	cur, _ := os.ReadFile(path)
+	if !strings.HasSuffix(string(old), ": before\n") || !strings.HasSuffix(string(cur), ": after\n") {
+		t.Errorf("rotated %q, current %q", old, cur)
+	}
+	if s.Path() != path {
+		t.Errorf("Path() = %q", s.Path())
+	}
+}
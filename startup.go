@@ -0,0 +1,67 @@
+package golog
+
+import (
+	"fmt"
+	"time"
+)
+
+// earlyRecord is a record kept by BufferUntilConfigured.
+type earlyRecord struct {
+	level int32
+	t     time.Time
+	rec   []byte
+}
+
+func BufferUntilConfigured(maxLines int) {
+	std().BufferUntilConfigured(maxLines)
+}
+
+// BufferUntilConfigured keeps the last maxLines records logged from now
+// on, besides writing them to stderr as usual, until SetFile, SetOutput,
+// SetSink or ApplyConfig picks an output: then they are written to it
+// ahead of anything new, with their original timestamps, so the log file
+// has the startup lines too. If more records were logged, a note says
+// how many of the oldest are missing. maxLines <= 0 stops buffering and
+// drops what was kept.
+func (l *Logger) BufferUntilConfigured(maxLines int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if maxLines <= 0 {
+		l.early, l.earlyMax, l.earlyDropped = nil, 0, 0
+		return
+	}
+	l.earlyMax = maxLines
+	if over := len(l.early) - maxLines; over > 0 {
+		l.early = append(l.early[:0], l.early[over:]...)
+		l.earlyDropped += over
+	}
+}
+
+// keepEarly buffers a record for replayEarly, l.mu must be held.
+func (l *Logger) keepEarly(level int32, t time.Time, rec []byte) {
+	if len(l.early) == l.earlyMax {
+		copy(l.early, l.early[1:])
+		l.early = l.early[:len(l.early)-1]
+		l.earlyDropped++
+	}
+	l.early = append(l.early, earlyRecord{level, t, append([]byte(nil), rec...)})
+}
+
+// replayEarly writes the records kept by BufferUntilConfigured to the
+// new output and stops buffering, l.mu must be held.
+func (l *Logger) replayEarly() {
+	if l.earlyMax == 0 {
+		return
+	}
+	if l.earlyDropped > 0 && len(l.early) > 0 {
+		var note []byte
+		l.formatNote(&note, l.early[0].t, LEVEL_WARNING)
+		n := len(note)
+		note = fmt.Appendf(note, "%d startup records dropped, the buffer holds %d", l.earlyDropped, l.earlyMax)
+		l.emit(LEVEL_WARNING, l.early[0].t, terminate(note, n, l.eol))
+	}
+	for _, r := range l.early {
+		l.emit(r.level, r.t, r.rec)
+	}
+	l.early, l.earlyMax, l.earlyDropped = nil, 0, 0
+}
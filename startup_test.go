@@ -0,0 +1,69 @@
+package golog
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestBufferUntilConfigured(t *testing.T) {
+	buf := captureGlobal(t, LEVEL_INFO)
+	BufferUntilConfigured(10)
+
+	Info("parsing flags")
+	Warn("unknown option %q", "-x")
+	Info("loading config")
+	if strings.Count(buf.String(), "\n") != 3 {
+		t.Fatalf("not written to the default output:\n%s", buf.String())
+	}
+
+	path := filepath.Join(t.TempDir(), "app.log")
+	SetFile(path)
+	defer std().out.(io.Closer).Close()
+	Info("started")
+	Info("started")
+
+	data, _ := os.ReadFile(path)
+	re := regexp.MustCompile(`^` +
+		headerRe + `\[INFO\] startup_test.go:\d+: parsing flags\n` +
+		headerRe + `\[WARNING\] startup_test.go:\d+: unknown option "-x"\n` +
+		headerRe + `\[INFO\] startup_test.go:\d+: loading config\n` +
+		`(` + headerRe + `\[INFO\] startup_test.go:\d+: started\n){2}$`)
+	if !re.Match(data) {
+		t.Errorf("log file has:\n%s", data)
+	}
+	if !strings.HasPrefix(string(data), buf.String()[:len("2006-01-02 15:04:05.000000")]) {
+		t.Errorf("timestamps changed:\n%s\n%s", data, buf.String())
+	}
+}
+
+func TestBufferUntilConfiguredDrops(t *testing.T) {
+	captureGlobal(t, LEVEL_INFO)
+	BufferUntilConfigured(2)
+	for i := 0; i < 5; i++ {
+		Info("line %d", i)
+	}
+
+	r, _ := NewRingLogger(10)
+	SetSink(r)
+	var got []string
+	for _, e := range r.Entries() {
+		got = append(got, e.Line[strings.Index(e.Line, ": ")+2:])
+	}
+	want := []string{
+		"3 startup records dropped, the buffer holds 2\n",
+		"line 3\n",
+		"line 4\n",
+	}
+	if strings.Join(got, "") != strings.Join(want, "") {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	Info("after")
+	if n := len(r.Entries()); n != 4 {
+		t.Errorf("%d entries, want 4", n)
+	}
+}
@@ -0,0 +1,197 @@
+package golog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// after this many consecutive write errors the output is considered
+// broken and writes are suspended for degradedBackoff.
+var (
+	degradeAfter    = 5
+	degradedBackoff = time.Second
+)
+
+// ErrDegraded is returned for records that were not written because the
+// output failed repeatedly and writes are suspended for a while.
+var ErrDegraded = errors.New("golog: output degraded, record suppressed")
+
+// Stats is a snapshot of a Logger's counters.
+type Stats struct {
+	Degraded      bool      // writes are suspended after repeated errors
+	DegradedSince time.Time // zero when healthy
+	WriteErrors   uint64    // failed writes since the logger was created
+	Suppressed    uint64    // records skipped while degraded
+	Dropped       uint64    // records dropped by the write policy, not yet reported
+	Filtered      uint64    // records dropped by filters, see AddFilter
+}
+
+// Counters are running totals of a Logger's output, e.g. for metrics.
+type Counters struct {
+	Lines        [LEVEL_VERBOSE + 1]uint64 // records written, by level
+	BytesWritten uint64
+	WriteErrors  uint64
+	Dropped      uint64 // records dropped by the write policy
+	Rotations    uint64
+}
+
+// counters are updated atomically, see Counters.
+type counters struct {
+	lines     [LEVEL_VERBOSE + 1]uint64
+	bytes     uint64
+	dropped   uint64
+	rotations uint64
+}
+
+func (c *counters) written(level int32, n int) {
+	if level >= 0 && int(level) < len(c.lines) {
+		atomic.AddUint64(&c.lines[level], 1)
+	}
+	atomic.AddUint64(&c.bytes, uint64(n))
+}
+
+func GetCounters() Counters {
+	return std().Counters()
+}
+
+func (l *Logger) Counters() Counters {
+	c := &l.counters
+	var cs Counters
+	for i := range c.lines {
+		cs.Lines[i] = atomic.LoadUint64(&c.lines[i])
+	}
+	cs.BytesWritten = atomic.LoadUint64(&c.bytes)
+	cs.Dropped = atomic.LoadUint64(&c.dropped)
+	cs.Rotations = atomic.LoadUint64(&c.rotations)
+
+	h := &l.health
+	h.mu.Lock()
+	cs.WriteErrors = h.writeErrors
+	h.mu.Unlock()
+	return cs
+}
+
+// health tracks write errors so that a full disk does not make every
+// log call pay for a failing syscall.
+type health struct {
+	mu          sync.Mutex
+	failures    int // consecutive
+	degraded    bool
+	since       time.Time // first failure of the current episode
+	retryAt     time.Time
+	lost        uint64 // records lost in the current episode
+	reportLost  uint64 // lost in episodes that ended, for lossNote
+	reportSpan  time.Duration
+	writeErrors uint64
+	suppressed  uint64
+
+	lastErr     error // see LastError
+	lastErrAt   time.Time
+	rotateErr   error // last rotation failed, see HealthCheck
+	rotateErrAt time.Time
+}
+
+func GetStats() Stats {
+	return std().Stats()
+}
+
+func (l *Logger) Stats() Stats {
+	h := &l.health
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	st := Stats{
+		Degraded:    h.degraded,
+		WriteErrors: h.writeErrors,
+		Suppressed:  h.suppressed,
+		Dropped:     atomic.LoadUint64(&l.dropped),
+		Filtered:    atomic.LoadUint64(&l.filtered),
+	}
+	if h.degraded {
+		st.DegradedSince = h.since
+	}
+	return st
+}
+
+// write sends one record to out. While the output is degraded records
+// are counted and skipped until the backoff expires; once a write
+// succeeds again the loss is kept for lossNote.
+func (l *Logger) write(out io.Writer, rec []byte) error {
+	if out == nil {
+		out = os.Stderr
+	}
+	return l.tracked(func() error {
+		_, err := writeDest(out, rec)
+		return err
+	})
+}
+
+// tracked runs do, a write to the output, with the error tracking and
+// degraded mode of write.
+func (l *Logger) tracked(do func() error) error {
+	h := &l.health
+	now := time.Now()
+
+	h.mu.Lock()
+	if h.degraded && now.Before(h.retryAt) {
+		h.suppressed++
+		h.lost++
+		h.mu.Unlock()
+		return ErrDegraded
+	}
+	h.mu.Unlock()
+
+	err := do()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err != nil {
+		h.failures++
+		h.writeErrors++
+		h.lastErr, h.lastErrAt = err, now
+		h.lost++
+		if h.lost == 1 {
+			h.since = now
+		}
+		if h.failures >= degradeAfter {
+			h.degraded = true
+			h.retryAt = now.Add(degradedBackoff)
+		}
+		return err
+	}
+
+	h.failures = 0
+	if h.lost > 0 {
+		h.reportLost += h.lost
+		h.reportSpan += now.Sub(h.since)
+		h.degraded = false
+		h.since = time.Time{}
+		h.lost = 0
+	}
+	return nil
+}
+
+// lossNote returns a CRITICAL record telling how many records were lost
+// before the output recovered, or nil if there is nothing to report.
+// l.mu must be held.
+func (l *Logger) lossNote(now time.Time) []byte {
+	h := &l.health
+	h.mu.Lock()
+	lost, span := h.reportLost, h.reportSpan
+	h.reportLost, h.reportSpan = 0, 0
+	h.mu.Unlock()
+	if lost == 0 {
+		return nil
+	}
+
+	var note []byte
+	l.formatNote(&note, now, LEVEL_CRITICAL)
+	n := len(note)
+	note = fmt.Appendf(note, "log output recovered, %d records lost over %v", lost, span)
+	return terminate(note, n, l.eol)
+}
@@ -0,0 +1,86 @@
+package golog
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// fullDisk fails every write with ENOSPC while full is set.
+type fullDisk struct {
+	full bool
+	buf  bytes.Buffer
+}
+
+func (w *fullDisk) Write(p []byte) (int, error) {
+	if w.full {
+		return 0, syscall.ENOSPC
+	}
+	return w.buf.Write(p)
+}
+
+func TestDegradedOnWriteErrors(t *testing.T) {
+	oldBackoff := degradedBackoff
+	defer func() { degradedBackoff = oldBackoff }()
+	degradedBackoff = 20 * time.Millisecond
+
+	w := &fullDisk{full: true}
+	l := &Logger{out: w, level: LEVEL_INFO}
+
+	for i := 0; i < 20; i++ {
+		l.output(LEVEL_INFO, "line %d", i)
+	}
+	st := l.Stats()
+	if !st.Degraded || st.DegradedSince.IsZero() {
+		t.Fatalf("expected degraded stats, got %+v", st)
+	}
+	if st.WriteErrors != uint64(degradeAfter) || st.Suppressed != uint64(20-degradeAfter) {
+		t.Fatalf("unexpected counters %+v", st)
+	}
+
+	w.full = false
+	time.Sleep(degradedBackoff)
+	l.output(LEVEL_INFO, "back")
+
+	if st := l.Stats(); st.Degraded {
+		t.Fatalf("still degraded after recovery: %+v", st)
+	}
+	out := w.buf.String()
+	if !strings.Contains(out, ": back\n") ||
+		!strings.Contains(out, "[CRITICAL] golog:0: log output recovered, 20 records lost over ") {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+}
+
+func TestCounters(t *testing.T) {
+	var buf bytes.Buffer
+	l := &Logger{out: &buf, level: LEVEL_INFO}
+	l.output(LEVEL_INFO, "one")
+	l.output(LEVEL_INFO, "two")
+	l.output(LEVEL_ERROR, "three")
+	l.output(LEVEL_DEBUG, "hidden")
+
+	c := l.Counters()
+	if c.Lines[LEVEL_INFO] != 2 || c.Lines[LEVEL_ERROR] != 1 || c.Lines[LEVEL_DEBUG] != 0 {
+		t.Errorf("lines %v", c.Lines)
+	}
+	if c.BytesWritten != uint64(buf.Len()) {
+		t.Errorf("%d bytes counted, %d written", c.BytesWritten, buf.Len())
+	}
+
+	path := filepath.Join(t.TempDir(), "app.log")
+	l = &Logger{level: LEVEL_INFO}
+	if err := l.openFile(path); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { l.out.(io.Closer).Close() }()
+	l.rotate(path + ".1")
+	l.rotate(path + ".2")
+	if c := l.Counters(); c.Rotations != 2 {
+		t.Errorf("%d rotations counted", c.Rotations)
+	}
+}
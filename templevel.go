@@ -0,0 +1,109 @@
+package golog
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// tempLevel is an override set by TempLevel.
+type tempLevel struct {
+	level int32
+	timer *time.Timer // set by TempLevelFor, protected by Logger.tempMu
+}
+
+// TempLevel sets the level of the global logger until the returned
+// function is called:
+//
+//	restore := golog.TempLevel(golog.LEVEL_DEBUG)
+//	defer restore()
+//
+// Overrides nest: the last one set applies, and restoring one, in any
+// order and from any goroutine, goes back to the last one still active,
+// or to the level set by SetLevel once none is. SetLevel while overridden
+// takes effect at once and is what the level goes back to. Calling
+// restore more than once is harmless.
+//
+// The records telling about the change show the caller of TempLevel and
+// of restore, or no caller, golog:0, for a restore by TempLevelFor.
+func TempLevel(level int32) (restore func()) {
+	return std().root().pushLevel(level, 0)
+}
+
+// TempLevelFor is TempLevel, restoring the level after d at the latest.
+func TempLevelFor(d time.Duration, level int32) (restore func()) {
+	return std().root().pushLevel(level, d)
+}
+
+// TempLevel overrides the level of l, see the TempLevel function.
+func (l *Logger) TempLevel(level int32) (restore func()) {
+	return l.root().pushLevel(level, 0)
+}
+
+// TempLevelFor overrides the level of l for d at the most, see the
+// TempLevel function.
+func (l *Logger) TempLevelFor(d time.Duration, level int32) (restore func()) {
+	return l.root().pushLevel(level, d)
+}
+
+// setLevel sets the level, the base one if overridden by TempLevel.
+func (l *Logger) setLevel(level int32) {
+	l.tempMu.Lock()
+	l.tempBase = level
+	atomic.StoreInt32(&l.level, level)
+	l.tempMu.Unlock()
+}
+
+// pushLevel must be called directly by the exported TempLevel functions.
+func (l *Logger) pushLevel(level int32, d time.Duration) func() {
+	t := &tempLevel{level: level}
+	restore := func() { l.popLevel(t, 3) }
+
+	l.tempMu.Lock()
+	if len(l.tempStack) == 0 {
+		l.tempBase = atomic.LoadInt32(&l.level)
+	}
+	l.tempStack = append(l.tempStack, t)
+	atomic.StoreInt32(&l.level, level)
+	if d > 0 {
+		t.timer = time.AfterFunc(d, func() { l.popLevel(t, 0) })
+	}
+	l.tempMu.Unlock()
+
+	l.outputDepth(3, LEVEL_CRITICAL, "", "set log level to %v temporarily", level)
+	return restore
+}
+
+// popLevel removes t, wherever it is in the stack, and applies the level
+// of the override on top, or the base level; with t gone already it does
+// nothing. The record shows the caller calldepth frames up, as counted
+// by outputDepth, or no caller with 0.
+func (l *Logger) popLevel(t *tempLevel, calldepth int) {
+	l.tempMu.Lock()
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+	found := false
+	for i, o := range l.tempStack {
+		if o == t {
+			l.tempStack = append(l.tempStack[:i], l.tempStack[i+1:]...)
+			found = true
+			break
+		}
+	}
+	if !found {
+		l.tempMu.Unlock()
+		return
+	}
+	level := l.tempBase
+	if n := len(l.tempStack); n > 0 {
+		level = l.tempStack[n-1].level
+	}
+	atomic.StoreInt32(&l.level, level)
+	l.tempMu.Unlock()
+
+	if calldepth == 0 {
+		l.outputRecord(0, LEVEL_CRITICAL, "", nil, recordNoCaller, "restored log level to %v", level)
+		return
+	}
+	l.outputDepth(calldepth, LEVEL_CRITICAL, "", "restored log level to %v", level)
+}
@@ -0,0 +1,84 @@
+package golog
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTempLevel(t *testing.T) {
+	buf := captureGlobal(t, LEVEL_INFO)
+
+	outer := TempLevel(LEVEL_DEBUG)
+	inner := TempLevel(LEVEL_VERBOSE)
+	if GetLevel() != LEVEL_VERBOSE {
+		t.Fatalf("level %v with two overrides", GetLevel())
+	}
+	// restored out of order, the inner override still applies.
+	outer()
+	if GetLevel() != LEVEL_VERBOSE {
+		t.Fatalf("level %v after restoring the outer override", GetLevel())
+	}
+	inner()
+	inner()
+	if GetLevel() != LEVEL_INFO {
+		t.Fatalf("level %v after restoring both", GetLevel())
+	}
+
+	// SetLevel while overridden is the level restored to.
+	restore := TempLevel(LEVEL_DEBUG)
+	SetLevel(LEVEL_WARNING)
+	if GetLevel() != LEVEL_WARNING {
+		t.Fatalf("SetLevel while overridden: level %v", GetLevel())
+	}
+	restore()
+	if GetLevel() != LEVEL_WARNING {
+		t.Fatalf("level %v after restore, want the one set by SetLevel", GetLevel())
+	}
+
+	out := buf.String()
+	if strings.Count(out, "set log level to 7 temporarily") != 2 ||
+		strings.Count(out, "restored log level to") != 3 ||
+		!strings.HasSuffix(out, "restored log level to 4\n") {
+		t.Errorf("got:\n%s", out)
+	}
+	// the records show who changed the level.
+	if strings.Count(out, "templevel_test.go:") != 6 || strings.Contains(out, "templevel.go:") {
+		t.Errorf("callers not shown:\n%s", out)
+	}
+}
+
+func TestTempLevelFor(t *testing.T) {
+	var buf bytes.Buffer
+	l := &Logger{out: &buf, level: LEVEL_NOTICE}
+
+	l.TempLevelFor(10*time.Millisecond, LEVEL_DEBUG)
+	deadline := time.Now().Add(5 * time.Second)
+	for l.GetLevel() != LEVEL_NOTICE {
+		if time.Now().After(deadline) {
+			t.Fatalf("level not restored after the duration")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// the timer racing an explicit restore, and overrides from other
+	// goroutines, always end at the base level.
+	for i := 0; i < 50; i++ {
+		var wg sync.WaitGroup
+		for g := 0; g < 4; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				restore := l.TempLevelFor(time.Millisecond, LEVEL_VERBOSE)
+				time.Sleep(time.Millisecond)
+				restore()
+			}()
+		}
+		wg.Wait()
+		if l.GetLevel() != LEVEL_NOTICE {
+			t.Fatalf("level %v after all overrides ended", l.GetLevel())
+		}
+	}
+}
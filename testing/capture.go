@@ -0,0 +1,91 @@
+// Package testing helps testing that code logs what it should. Its name
+// is that of the standard package, import it under another one:
+//
+//	import gologtesting "github.com/idning/golog/testing"
+//
+//	func TestSave(t *testing.T) {
+//		c := gologtesting.NewCapture(t)
+//		save(nil)
+//		c.AssertContains(t, golog.LEVEL_ERROR, "nothing to save")
+//	}
+package testing
+
+import (
+	"strings"
+	"sync"
+	stdtesting "testing"
+	"time"
+
+	"github.com/idning/golog"
+)
+
+// A Capture keeps the records logged by the package level functions of
+// golog during a test.
+type Capture struct {
+	mu      sync.Mutex
+	entries []golog.Entry
+}
+
+// NewCapture makes the global logger log every level to a new Capture,
+// and to t.Log, until the test ends. (A function can not be called
+// Capture as well as the type.)
+func NewCapture(t stdtesting.TB) *Capture {
+	c := &Capture{}
+	l := golog.NewTestLogger(t)
+	l.AddSink(c)
+	orig := golog.GetGlobalLogger()
+	golog.SetGlobalLogger(l)
+	t.Cleanup(func() { golog.SetGlobalLogger(orig) })
+	return c
+}
+
+// Entries returns a copy of the records captured so far, oldest first.
+func (c *Capture) Entries() []golog.Entry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]golog.Entry(nil), c.entries...)
+}
+
+// AssertContains fails t unless a record at level contains substr.
+func (c *Capture) AssertContains(t stdtesting.TB, level int32, substr string) {
+	t.Helper()
+	for _, e := range c.Entries() {
+		if int32(e.Level) == level && strings.Contains(e.Line, substr) {
+			return
+		}
+	}
+	t.Errorf("no %v record containing %q in:\n%s", golog.Level(level), substr, c.dump())
+}
+
+// AssertCount fails t unless exactly n records were logged at level.
+func (c *Capture) AssertCount(t stdtesting.TB, level int32, n int) {
+	t.Helper()
+	got := 0
+	for _, e := range c.Entries() {
+		if int32(e.Level) == level {
+			got++
+		}
+	}
+	if got != n {
+		t.Errorf("%d %v records, want %d, in:\n%s", got, golog.Level(level), n, c.dump())
+	}
+}
+
+func (c *Capture) dump() string {
+	var b strings.Builder
+	for _, e := range c.Entries() {
+		b.WriteString(e.Line)
+	}
+	return b.String()
+}
+
+// Write, Reopen and Close make c a golog.Sink.
+func (c *Capture) Write(level int32, t time.Time, line []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = append(c.entries, golog.Entry{Time: t, Level: golog.Level(level), Line: string(line)})
+	return nil
+}
+
+func (c *Capture) Reopen() error { return nil }
+func (c *Capture) Close() error  { return nil }
@@ -0,0 +1,39 @@
+package testing
+
+import (
+	"fmt"
+	stdtesting "testing"
+
+	"github.com/idning/golog"
+)
+
+// recordingT is a TB whose failures are only recorded.
+type recordingT struct {
+	stdtesting.TB
+	failures []string
+}
+
+func (r *recordingT) Errorf(format string, v ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprintf(format, v...))
+}
+
+func TestCapture(t *stdtesting.T) {
+	c := NewCapture(t)
+	golog.Error("save %s: %v", "user", "disk full")
+	golog.Debug("retrying")
+	golog.Debug("retrying")
+
+	c.AssertContains(t, golog.LEVEL_ERROR, "disk full")
+	c.AssertCount(t, golog.LEVEL_DEBUG, 2)
+	c.AssertCount(t, golog.LEVEL_WARNING, 0)
+	if e := c.Entries(); len(e) != 3 || e[0].Level != golog.LEVEL_ERROR {
+		t.Errorf("Entries() = %+v", e)
+	}
+
+	r := &recordingT{TB: t}
+	c.AssertContains(r, golog.LEVEL_DEBUG, "disk full")
+	c.AssertCount(r, golog.LEVEL_ERROR, 2)
+	if len(r.failures) != 2 {
+		t.Errorf("failures: %q", r.failures)
+	}
+}
@@ -0,0 +1,39 @@
+package golog
+
+import (
+	"strings"
+	"testing"
+)
+
+// testWriter sends every log line to t.Log, so the output shows up
+// under the test that produced it and only when that test fails (or -v).
+type testWriter struct {
+	t testing.TB
+}
+
+func (w testWriter) Write(p []byte) (int, error) {
+	w.t.Helper()
+	w.t.Log(strings.TrimSuffix(string(p), "\n"))
+	return len(p), nil
+}
+
+// NewTestLogger returns a Logger that forwards each line to t.Log.
+// All levels are enabled.
+func NewTestLogger(t testing.TB) *Logger {
+	return &Logger{
+		out:          testWriter{t},
+		level:        LEVEL_VERBOSE,
+		microseconds: true,
+		shortfile:    true,
+	}
+}
+
+// UseTestLogger makes the package level functions log to t for the
+// duration of the test; the previous logger is restored on cleanup.
+func UseTestLogger(t testing.TB) {
+	orig := GetGlobalLogger()
+	SetGlobalLogger(NewTestLogger(t))
+	t.Cleanup(func() {
+		SetGlobalLogger(orig)
+	})
+}
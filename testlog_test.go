@@ -0,0 +1,54 @@
+package golog
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+type recordingTB struct {
+	testing.TB
+	lines []string
+}
+
+func (r *recordingTB) Helper() {}
+
+func (r *recordingTB) Log(args ...interface{}) {
+	r.lines = append(r.lines, fmt.Sprint(args...))
+}
+
+func TestNewTestLogger(t *testing.T) {
+	tb := &recordingTB{TB: t}
+	l := NewTestLogger(tb)
+	l.output(LEVEL_DEBUG, "hello %s", "world")
+
+	if len(tb.lines) != 1 {
+		t.Fatalf("got %d lines, want 1: %q", len(tb.lines), tb.lines)
+	}
+	line := tb.lines[0]
+	if !strings.HasSuffix(line, ": hello world") || !strings.Contains(line, "[DEBUG]") {
+		t.Errorf("unexpected line %q", line)
+	}
+}
+
+func TestUseTestLogger(t *testing.T) {
+	orig := GetGlobalLogger()
+	tb := &recordingTB{}
+	t.Run("sub", func(t *testing.T) {
+		tb.TB = t
+		UseTestLogger(tb)
+		Info("captured %d", 1)
+		if std() == orig {
+			t.Fatal("global logger not replaced")
+		}
+	})
+
+	if std() != orig {
+		t.Fatal("global logger not restored")
+	}
+	re := regexp.MustCompile(`\[INFO\] testlog_test.go:\d+: captured 1$`)
+	if len(tb.lines) != 1 || !re.MatchString(tb.lines[0]) {
+		t.Fatalf("unexpected lines %q", tb.lines)
+	}
+}
@@ -0,0 +1,43 @@
+package golog
+
+import (
+	"fmt"
+	"time"
+)
+
+func noop() {}
+
+// Timed returns a func that logs the message followed by the time elapsed
+// since Timed was called, and the time again in milliseconds as the
+// duration_ms field, e.g. "rebuild index took 1.2s duration_ms=1200.000":
+//
+//	defer golog.Timed(golog.LEVEL_DEBUG, "rebuild index")()
+//
+// If level is disabled when Timed is called, the returned func does
+// nothing.
+func Timed(level int32, format string, v ...interface{}) func() {
+	return timed(0, level, format, v...)
+}
+
+// TimedThreshold is like Timed, but only logs when more than d has
+// elapsed.
+func TimedThreshold(d time.Duration, level int32, format string, v ...interface{}) func() {
+	return timed(d, level, format, v...)
+}
+
+// timed must be called directly by Timed or TimedThreshold.
+func timed(d time.Duration, level int32, format string, v ...interface{}) func() {
+	l := std()
+	if !l.enabledAt(2, level) {
+		return noop
+	}
+	start := time.Now()
+	return func() {
+		elapsed := time.Since(start)
+		if elapsed <= d && d > 0 {
+			return
+		}
+		ms := &field{"duration_ms", fmt.Sprintf("%.3f", float64(elapsed)/float64(time.Millisecond))}
+		l.withFields(ms).outputDepth(2, level, "", format+" took %v", append(v[:len(v):len(v)], elapsed)...)
+	}
+}
@@ -0,0 +1,34 @@
+package golog
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestTimed(t *testing.T) {
+	buf := captureGlobal(t, LEVEL_INFO)
+
+	func() {
+		defer Timed(LEVEL_INFO, "rebuild %s", "index")()
+		defer TimedThreshold(time.Hour, LEVEL_INFO, "slow")()
+		defer Timed(LEVEL_DEBUG, "hidden")()
+		time.Sleep(time.Millisecond)
+	}()
+
+	re := regexp.MustCompile(`^` + headerRe +
+		`\[INFO\] timed_test.go:\d+: rebuild index took \d+(\.\d+)?[µm]?s duration_ms=\d+\.\d{3}\n$`)
+	if !re.MatchString(buf.String()) {
+		t.Errorf("got %q", buf.String())
+	}
+}
+
+func TestTimedDisabled(t *testing.T) {
+	captureGlobal(t, LEVEL_INFO)
+	allocs := testing.AllocsPerRun(100, func() {
+		Timed(LEVEL_DEBUG, "hidden")()
+	})
+	if allocs != 0 {
+		t.Errorf("disabled Timed allocated %v times", allocs)
+	}
+}
@@ -0,0 +1,76 @@
+package golog
+
+import (
+	"strconv"
+	"sync/atomic"
+)
+
+// VerboseLogger is returned by V (Verbose already names the function);
+// its methods log at LEVEL_VERBOSE with a "Vn" tag after the header, or
+// do nothing if the verbosity was too low.
+type VerboseLogger struct {
+	l *Logger // nil when disabled
+	v int
+}
+
+// SetVerbosity sets how detailed LEVEL_VERBOSE tracing is: V(n) logs
+// only when n <= v. The default is 0.
+func SetVerbosity(v int) {
+	std().SetVerbosity(v)
+}
+
+func GetVerbosity() int {
+	return std().GetVerbosity()
+}
+
+func (l *Logger) SetVerbosity(v int) {
+	atomic.StoreInt32(&l.root().verbosity, int32(v))
+}
+
+func (l *Logger) GetVerbosity() int {
+	return int(atomic.LoadInt32(&l.root().verbosity))
+}
+
+// V reports whether tracing at verbosity v is on, e.g.
+//
+//	golog.V(2).Info("cache miss for %s", key)
+//
+// The records are at LEVEL_VERBOSE, so they still need that level (or a
+// path override, see SetLevelForPath) to be enabled.
+func V(v int) VerboseLogger {
+	return std().V(v)
+}
+
+func (l *Logger) V(v int) VerboseLogger {
+	if int32(v) > atomic.LoadInt32(&l.root().verbosity) {
+		return VerboseLogger{}
+	}
+	return VerboseLogger{l, v}
+}
+
+// Enabled tells whether v logs anything, for tracing that is
+// expensive to prepare.
+func (v VerboseLogger) Enabled() bool {
+	return v.l != nil
+}
+
+func (v VerboseLogger) Printf(format string, a ...interface{}) {
+	if v.l != nil {
+		v.l.outputDepth(2, LEVEL_VERBOSE, vTag(v.v), format, a...)
+	}
+}
+
+func (v VerboseLogger) Info(format string, a ...interface{}) {
+	if v.l != nil {
+		v.l.outputDepth(2, LEVEL_VERBOSE, vTag(v.v), format, a...)
+	}
+}
+
+var vTags = [...]string{"V0", "V1", "V2", "V3", "V4", "V5", "V6", "V7", "V8", "V9"}
+
+func vTag(v int) string {
+	if v >= 0 && v < len(vTags) {
+		return vTags[v]
+	}
+	return "V" + strconv.Itoa(v)
+}
@@ -0,0 +1,53 @@
+package golog
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestV(t *testing.T) {
+	buf := captureGlobal(t, LEVEL_VERBOSE)
+	SetVerbosity(2)
+	defer SetVerbosity(0)
+
+	for i := 0; i < 4; i++ {
+		V(i).Info("depth %d", i)
+	}
+	V(1).Printf("printf")
+	got := buf.String()
+	for _, want := range []string{"[VERB] verbose_test.go:", ": V0 depth 0\n", ": V2 depth 2\n", ": V1 printf\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "depth 3") {
+		t.Errorf("V(3) logged at verbosity 2:\n%s", got)
+	}
+	if V(3).Enabled() || !V(2).Enabled() {
+		t.Error("wrong Enabled")
+	}
+
+	SetLevel(LEVEL_DEBUG)
+	buf.Reset()
+	V(0).Info("below the level")
+	if buf.Len() != 0 {
+		t.Errorf("logged with LEVEL_VERBOSE disabled: %q", buf.String())
+	}
+}
+
+func BenchmarkVDisabled(b *testing.B) {
+	l := NewDiscardLogger()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.V(1).Info("trace %d %s", 1, "x")
+	}
+}
+
+func BenchmarkVEnabled(b *testing.B) {
+	l := &Logger{out: io.Discard, level: LEVEL_VERBOSE, verbosity: 1}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.V(1).Info("trace %d", 1)
+	}
+}
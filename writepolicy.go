@@ -0,0 +1,245 @@
+package golog
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// size of the hand-off queue used by DropWithTimeout.
+const writeQueueSize = 256
+
+// a queuedRecord is a record handed to the writer goroutine, with the
+// level and time the sinks added by AddSink get besides.
+type queuedRecord struct {
+	level int32
+	t     time.Time
+	rec   []byte
+}
+
+// how many queued records are written at once by default, see SetBatch.
+const (
+	defaultBatchRecords = 64
+	defaultBatchBytes   = 64 << 10
+)
+
+// ErrDropped is returned for a record that was dropped because the
+// output did not accept it within the write timeout.
+var ErrDropped = errors.New("golog: record dropped, output is blocked")
+
+// A WritePolicy tells the logger what to do when its output is slow.
+type WritePolicy struct {
+	timeout time.Duration
+}
+
+// Block makes every log call wait until its record is written. This is
+// the default.
+var Block = WritePolicy{}
+
+// DropWithTimeout hands records to a writer goroutine and waits at most
+// d for it to accept them; records that can not be handed over in time
+// are dropped and counted. Once the output accepts writes again a
+// summary of the dropped records is logged.
+//
+// This bounds the latency of a log call even when the output is wedged
+// (a hung NFS mount, a stuck network peer), or a sink added by AddSink
+// is: the writer goroutine writes the records to those sinks too, and
+// their write errors go to OnError as "sink" errors instead of being
+// returned. A sink set by SetSink is still written synchronously.
+func DropWithTimeout(d time.Duration) WritePolicy {
+	return WritePolicy{timeout: d}
+}
+
+func SetWritePolicy(p WritePolicy) {
+	std().SetWritePolicy(p)
+}
+
+func (l *Logger) SetWritePolicy(p WritePolicy) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.setWriteTimeout(p.timeout)
+}
+
+// setWriteTimeout applies a write policy, l.mu must be held.
+func (l *Logger) setWriteTimeout(d time.Duration) {
+	l.writeTimeout = d
+	// the writer goroutine is started once and kept, so a caller that
+	// already picked up the queue can never send on a closed channel.
+	if d > 0 && l.queue == nil {
+		if l.batchRecords == 0 {
+			l.batchRecords, l.batchBytes = defaultBatchRecords, defaultBatchBytes
+		}
+		l.queue = make(chan queuedRecord, writeQueueSize)
+		go l.writeLoop(l.queue)
+	}
+}
+
+func SetWriteTimeout(d time.Duration) {
+	std().SetWriteTimeout(d)
+}
+
+// SetWriteTimeout is SetWritePolicy(DropWithTimeout(d)), or Block if d
+// <= 0: a log call waits at most d for its record to be taken for
+// writing, else the record is dropped, the call returns ErrDropped and
+// OnError gets it as a "write" error.
+func (l *Logger) SetWriteTimeout(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	l.SetWritePolicy(WritePolicy{timeout: d})
+}
+
+func SetBatch(maxRecords int, maxBytes int, maxDelay time.Duration) {
+	std().SetBatch(maxRecords, maxBytes, maxDelay)
+}
+
+// SetBatch sets how the writer goroutine of DropWithTimeout writes the
+// records queued: up to maxRecords at a time, and no more once they add
+// up to maxBytes (<= 0 is no limit), in a single Write. It waits up to
+// maxDelay for more records to come; with 0 it only takes those already
+// queued, adding no latency. Records are never split across writes. The
+// default is 64 records or 64KiB without delay; SetBatch(1, 0, 0) writes
+// every record on its own.
+func (l *Logger) SetBatch(maxRecords int, maxBytes int, maxDelay time.Duration) {
+	if maxRecords < 1 {
+		maxRecords = 1
+	}
+	if maxBytes < 0 {
+		maxBytes = 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.batchRecords, l.batchBytes, l.batchDelay = maxRecords, maxBytes, maxDelay
+}
+
+// Dropped returns the number of records dropped by the write policy
+// that have not been reported yet.
+func (l *Logger) Dropped() uint64 {
+	return atomic.LoadUint64(&l.dropped)
+}
+
+func (l *Logger) enqueue(q chan<- queuedRecord, rec queuedRecord, timeout time.Duration) error {
+	atomic.AddInt64(&l.pending, 1)
+	select {
+	case q <- rec:
+		atomic.StoreInt64(&l.queueFullSince, 0)
+		return nil
+	default:
+	}
+	atomic.CompareAndSwapInt64(&l.queueFullSince, 0, time.Now().UnixNano())
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case q <- rec:
+		return nil
+	case <-timer.C:
+		atomic.AddInt64(&l.pending, -1)
+		atomic.AddUint64(&l.dropped, 1)
+		atomic.AddUint64(&l.counters.dropped, 1)
+		return ErrDropped
+	}
+}
+
+// drain waits, at most d, until the records queued so far are written.
+// It returns false on timeout.
+func (l *Logger) drain(d time.Duration) bool {
+	deadline := time.Now().Add(d)
+	for atomic.LoadInt64(&l.pending) > 0 {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return true
+}
+
+func (l *Logger) writeLoop(q <-chan queuedRecord) {
+	var summary, batch []byte
+	var recs []queuedRecord
+	for rec := range q {
+		l.mu.RLock()
+		maxRecords, maxBytes, delay := l.batchRecords, l.batchBytes, l.batchDelay
+		l.mu.RUnlock()
+		recs = collectBatch(q, append(recs[:0], rec), maxRecords, maxBytes, delay)
+		n := len(recs)
+		batch = batch[:0]
+		for _, r := range recs {
+			batch = append(batch, r.rec...)
+		}
+
+		l.mu.RLock()
+		out, sinks := l.out, l.sinks
+		l.inflight.RLock()
+		if d := atomic.SwapUint64(&l.dropped, 0); d > 0 {
+			summary = summary[:0]
+			l.formatNote(&summary, time.Now().Round(0), LEVEL_WARNING)
+			m := len(summary)
+			summary = fmt.Appendf(summary, "dropped %d records, output blocked for more than %v",
+				d, l.writeTimeout)
+			summary = terminate(summary, m, l.eol)
+		}
+		l.mu.RUnlock()
+
+		for _, r := range recs {
+			l.reportError("sink", l.writeSinks(sinks, r.level, r.t, r.rec))
+		}
+		if len(summary) > 0 {
+			l.write(out, summary)
+			summary = summary[:0]
+		}
+		err := l.write(out, batch)
+		l.inflight.RUnlock()
+		if err != nil {
+			atomic.AddInt64(&l.pending, -int64(n))
+			continue
+		}
+		atomic.AddUint64(&l.counters.bytes, uint64(len(batch)))
+
+		l.mu.Lock()
+		note := l.lossNote(time.Now())
+		out = l.out
+		l.inflight.RLock()
+		l.mu.Unlock()
+		if note != nil {
+			l.write(out, note)
+		}
+		l.inflight.RUnlock()
+		atomic.AddInt64(&l.pending, -int64(n))
+	}
+}
+
+// collectBatch appends the records queued after the first one, already
+// in batch, within the limits of SetBatch.
+func collectBatch(q <-chan queuedRecord, batch []queuedRecord, maxRecords, maxBytes int,
+	delay time.Duration) []queuedRecord {
+
+	var timeout <-chan time.Time
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	size := len(batch[0].rec)
+	for len(batch) < maxRecords && (maxBytes <= 0 || size < maxBytes) {
+		if timeout == nil {
+			select {
+			case rec := <-q:
+				batch = append(batch, rec)
+				size += len(rec.rec)
+				continue
+			default:
+				return batch
+			}
+		}
+		select {
+		case rec := <-q:
+			batch = append(batch, rec)
+			size += len(rec.rec)
+		case <-timeout:
+			return batch
+		}
+	}
+	return batch
+}
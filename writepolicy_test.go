@@ -0,0 +1,208 @@
+package golog
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stuckWriter blocks every Write until release is closed.
+type stuckWriter struct {
+	release chan struct{}
+	mu      sync.Mutex
+	buf     bytes.Buffer
+}
+
+func (w *stuckWriter) Write(p []byte) (int, error) {
+	<-w.release
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *stuckWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func TestDropWithTimeout(t *testing.T) {
+	w := &stuckWriter{release: make(chan struct{})}
+	l := &Logger{out: w, level: LEVEL_INFO}
+	timeout := 5 * time.Millisecond
+	l.SetWritePolicy(DropWithTimeout(timeout))
+
+	// the writer goroutine holds a batch besides the queue.
+	n := writeQueueSize + defaultBatchRecords + 50
+	for i := 0; i < n; i++ {
+		start := time.Now()
+		l.output(LEVEL_INFO, "line %d", i)
+		if d := time.Since(start); d > 20*timeout {
+			t.Fatalf("log call %d took %v with a stuck output", i, d)
+		}
+	}
+	if l.Dropped() == 0 {
+		t.Fatal("expected dropped records")
+	}
+
+	close(w.release)
+	l.output(LEVEL_INFO, "recovered")
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(w.String(), "recovered") {
+		if time.Now().After(deadline) {
+			t.Fatalf("output did not recover:\n%s", w.String())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if !strings.Contains(w.String(), "[WARNING] golog:0: dropped ") {
+		t.Fatalf("missing drop summary:\n%s", w.String())
+	}
+}
+
+func TestSetWriteTimeout(t *testing.T) {
+	w := &stuckWriter{release: make(chan struct{})}
+	defer close(w.release)
+	l := &Logger{out: w, level: LEVEL_INFO}
+	var writeErrs int
+	var mu sync.Mutex
+	l.OnError(func(op string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if op == "write" && err == ErrDropped {
+			writeErrs++
+		}
+	})
+	l.SetWriteTimeout(time.Millisecond)
+
+	var err error
+	for i := 0; err == nil && i < 2*writeQueueSize+defaultBatchRecords; i++ {
+		err = l.output(LEVEL_INFO, "line %d", i)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if err != ErrDropped || writeErrs != 1 {
+		t.Errorf("output() = %v, %d write errors reported", err, writeErrs)
+	}
+}
+
+// slowWriter counts writes, each taking a while so records queue up.
+type slowWriter struct {
+	mu     sync.Mutex
+	writes int
+	buf    bytes.Buffer
+}
+
+func (w *slowWriter) Write(p []byte) (int, error) {
+	time.Sleep(20 * time.Microsecond)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.writes++
+	return w.buf.Write(p)
+}
+
+func TestBatchedWrites(t *testing.T) {
+	for _, c := range []struct {
+		records, bytes int
+		batched        bool
+	}{
+		{0, 0, true}, // defaults
+		{16, 256, true},
+		{1, 0, false},
+	} {
+		w := &slowWriter{}
+		s := &recordSink{}
+		l := &Logger{out: w, level: LEVEL_INFO, sinks: []Sink{s}}
+		if c.records > 0 {
+			l.SetBatch(c.records, c.bytes, time.Millisecond)
+		}
+		l.SetWritePolicy(DropWithTimeout(time.Minute))
+
+		const lines = 800
+		var wg sync.WaitGroup
+		for g := 0; g < 4; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < lines/4; i++ {
+					l.output(LEVEL_INFO, "line %d", i)
+				}
+			}()
+		}
+		wg.Wait()
+		if !l.drain(10 * time.Second) {
+			t.Fatal("queue not drained")
+		}
+
+		// the sink sees every record in order, as written without a queue.
+		if got, want := w.buf.String(), strings.Join(s.lines, ""); got != want {
+			t.Fatalf("SetBatch(%d, %d): file differs from the records", c.records, c.bytes)
+		}
+		if c.batched && w.writes >= lines/2 || !c.batched && w.writes != lines {
+			t.Errorf("SetBatch(%d, %d): %d writes for %d lines", c.records, c.bytes, w.writes, lines)
+		}
+	}
+}
+
+// blockedSink holds up every write until release is closed.
+type blockedSink struct {
+	recordSink
+	release chan struct{}
+}
+
+func (s *blockedSink) Write(level int32, t time.Time, line []byte) error {
+	<-s.release
+	return s.recordSink.Write(level, t, line)
+}
+
+func TestSlowSinkDoesNotBlock(t *testing.T) {
+	s := &blockedSink{release: make(chan struct{})}
+	l := &Logger{out: io.Discard, level: LEVEL_INFO}
+	l.AddSink(s)
+	l.SetWritePolicy(DropWithTimeout(time.Second))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 10; i++ {
+			l.output(LEVEL_INFO, "line %d", i)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("log calls wait for a blocked sink")
+	}
+
+	close(s.release)
+	if !l.drain(10 * time.Second) {
+		t.Fatal("queue not drained")
+	}
+	if len(s.lines) != 10 || !strings.HasSuffix(s.lines[9], ": line 9\n") {
+		t.Errorf("sink got %q", s.lines)
+	}
+}
+
+func benchmarkQueued(b *testing.B, maxRecords int) {
+	f, err := os.Create(filepath.Join(b.TempDir(), "bench.log"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer f.Close()
+	l := &Logger{out: f, level: LEVEL_INFO}
+	l.SetBatch(maxRecords, 0, 0)
+	l.SetWritePolicy(DropWithTimeout(time.Minute))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.output(LEVEL_INFO, "short line %d", i)
+	}
+	l.drain(time.Minute)
+}
+
+func BenchmarkQueuedPerLine(b *testing.B) { benchmarkQueued(b, 1) }
+func BenchmarkQueuedBatched(b *testing.B) { benchmarkQueued(b, defaultBatchRecords) }